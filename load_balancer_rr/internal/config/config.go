@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -24,8 +25,14 @@ var Logger *zap.Logger
 var Config = &struct {
 	Server  Server  `json:"server"`
 	Backend Backend `json:"backend"`
+	// Services are the named backend services, each routed by path prefix and/or host.
+	// Backend is the default service serving the requests that match no service.
+	Services []Service `json:"services"`
+	// Rules route the requests they match to a service, they are evaluated in order before the services.
+	Rules []Rule `json:"rules"`
 
-	// HealthCheckTickerTimeInSeconds defines the interval for health check ticks in seconds.
+	// HealthCheckTickerTimeInSeconds defines the default interval in seconds between the health checks of a backend,
+	// for the services that set no interval of their own.
 	HealthCheckTickerTimeInSeconds int64 `json:"healthCheckTickerTimeInSeconds"`
 }{}
 
@@ -34,12 +41,218 @@ type Server struct {
 	Port         int `json:"port"`
 	ReadTimeout  int `json:"readTimeout"`
 	WriteTimeout int `json:"writeTimeout"`
+	// Zone is the availability zone the load balancer runs in, backends in the same zone are preferred.
+	Zone string `json:"zone"`
+	// AdminPort is the port of the admin API listener, disabled when 0.
+	AdminPort int `json:"adminPort"`
+	// AdminToken is the bearer token required by the admin API endpoints changing the load balancer,
+	// they are refused when it is empty.
+	AdminToken string `json:"adminToken"`
+}
+
+// Service defines a named backend service along with the requests routed to it.
+type Service struct {
+	Name string `json:"name"`
+	// PathPrefix matches the requests whose path is or starts with the prefix, empty matches every path.
+	PathPrefix string `json:"pathPrefix"`
+	// Host matches the requests for the Host header, empty matches every host.
+	Host string `json:"host"`
+	Backend
+}
+
+// Rule routes the requests matching every one of its conditions to a service.
+type Rule struct {
+	// Service is the name of the service the matching requests are routed to.
+	Service string `json:"service"`
+	// Methods matches the requests with one of the HTTP methods, empty matches every method.
+	Methods    []string `json:"methods"`
+	PathPrefix string   `json:"pathPrefix"`
+	Headers    []Match  `json:"headers"`
+	Query      []Match  `json:"query"`
+}
+
+// Match defines a condition on a header or query parameter value. At most one of Exact, Prefix
+// and Regex can be set, when none is the header or query parameter only needs to be present.
+type Match struct {
+	Name   string `json:"name"`
+	Exact  string `json:"exact"`
+	Prefix string `json:"prefix"`
+	Regex  string `json:"regex"`
 }
 
 // Backend holds the configuration for backend services, including server router and endpoints.
 type Backend struct {
-	Routes   []string            `json:"routes"`
-	Endpoint map[string]Endpoint `json:"endpoints"`
+	// Algorithm selects the load balancing algorithm used by the server pool, defaults to round robin.
+	Algorithm string              `json:"algorithm"`
+	Routes    []Route             `json:"routes"`
+	Endpoint  map[string]Endpoint `json:"endpoints"`
+	// HealthCheck tunes how the health check results change the state of the backends.
+	HealthCheck HealthCheck `json:"healthCheck"`
+	// OutlierDetection ejects the backends failing the proxied requests.
+	OutlierDetection OutlierDetection `json:"outlierDetection"`
+	// CircuitBreaker stops sending requests to a failing backend until trial requests succeed again.
+	CircuitBreaker CircuitBreaker `json:"circuitBreaker"`
+	// DialTimeout is the timeout in seconds to connect to a backend, defaults to 30.
+	DialTimeout int `json:"dialTimeout"`
+	// ResponseTimeout is the timeout in seconds to receive the response headers of a backend, disabled when 0.
+	ResponseTimeout int `json:"responseTimeout"`
+	// HashKey selects the request attribute used by the consistent hash algorithm.
+	HashKey RequestKey `json:"hashKey"`
+	// BoundedLoadFactor caps the in-flight requests of a backend at this factor of the pool average
+	// for the bounded consistent hash algorithm, defaults to 1.25.
+	BoundedLoadFactor float64 `json:"boundedLoadFactor"`
+	// StickySession pins clients to the backend that served their first request.
+	StickySession StickySession `json:"stickySession"`
+	// MinHealthyBackends is the number of available backends a priority tier needs to keep receiving traffic,
+	// below it requests spill over to the next tier, defaults to 1.
+	MinHealthyBackends int `json:"minHealthyBackends"`
+	// MinLocalZoneBackends is the number of available backends in the load balancer zone needed to keep
+	// traffic local, below it requests spill over to the other zones, defaults to 1.
+	MinLocalZoneBackends int `json:"minLocalZoneBackends"`
+	// SlowStart is the window in seconds over which new or recovered backends ramp up to their
+	// full weight with the weighted algorithms, disabled when 0.
+	SlowStart int `json:"slowStart"`
+	// DrainTimeout is the time in seconds a draining backend is given to finish its in-flight requests
+	// before it is removed anyway, defaults to 30.
+	DrainTimeout int `json:"drainTimeout"`
+	// Canary sends a share of the requests to another service, e.g. running a new build.
+	Canary Canary `json:"canary"`
+	// Mirror sends a copy of a share of the requests to another service, discarding its responses.
+	Mirror Mirror `json:"mirror"`
+}
+
+// Mirror defines the share of the requests of a service shadowed to a mirror service.
+type Mirror struct {
+	// Service is the name of the mirror service, mirroring is disabled when empty.
+	Service string `json:"service"`
+	// Percent is the share of the requests copied to the mirror, 100 mirrors every request.
+	Percent float64 `json:"percent"`
+}
+
+// Canary defines the share of the requests of a service sent to a canary service.
+type Canary struct {
+	// Service is the name of the canary service, the split is disabled when empty.
+	Service string `json:"service"`
+	// Percent is the share of the requests sent to the canary, it can be changed at runtime through the admin API.
+	Percent float64 `json:"percent"`
+	// Sticky assigns requests to the canary by a hash of Key, so a user does not flip between versions.
+	Sticky bool       `json:"sticky"`
+	Key    RequestKey `json:"key"`
+}
+
+// StickySession defines the cookie based session affinity settings.
+type StickySession struct {
+	Enabled    bool   `json:"enabled"`
+	CookieName string `json:"cookieName"`
+	TTL        int    `json:"ttl"`
+	// SigningKey signs the cookie so clients cannot pin themselves to arbitrary backends.
+	SigningKey string `json:"signingKey"`
+}
+
+// RequestKey identifies an attribute of an incoming request used to route it.
+type RequestKey struct {
+	// Source is where the key is read from: header, cookie, query or ip.
+	Source string `json:"source"`
+	// Name is the header, cookie or query parameter name, unused for ip.
+	Name string `json:"name"`
+}
+
+// Route defines a single backend server along with its load balancing attributes.
+// It can be configured either as a plain URL string or as an object.
+type Route struct {
+	URL string `json:"url"`
+	// Weight is the relative share of traffic used by weighted algorithms, defaults to 1.
+	Weight int `json:"weight"`
+	// Priority is the failover tier of the backend, 0 is the primary tier and higher values are backups.
+	Priority int `json:"priority"`
+	// Zone is the availability zone of the backend.
+	Zone string `json:"zone"`
+}
+
+// UnmarshalJSON supports both the plain URL form and the object form of a route.
+func (r *Route) UnmarshalJSON(data []byte) error {
+	var rawURL string
+	if err := json.Unmarshal(data, &rawURL); err == nil {
+		*r = Route{URL: rawURL}
+		return nil
+	}
+
+	// alias drops the UnmarshalJSON method to avoid recursion
+	type alias Route
+	var route alias
+	if err := json.Unmarshal(data, &route); err != nil {
+		return err
+	}
+	*r = Route(route)
+	return nil
+}
+
+// HealthCheck defines how the health checks of the backends of a service are run and interpreted.
+type HealthCheck struct {
+	// Rise is the number of consecutive successful checks bringing a dead backend back, defaults to 1.
+	Rise int `json:"rise"`
+	// Fall is the number of consecutive failed checks marking an alive backend dead, defaults to 1.
+	Fall int `json:"fall"`
+	// Interval is the time in seconds between the checks of a backend, defaults to healthCheckTickerTimeInSeconds.
+	Interval int `json:"interval"`
+	// UnhealthyInterval is the time in seconds between the checks of a dead backend, defaults to Interval.
+	UnhealthyInterval int `json:"unhealthyInterval"`
+	// JitterPercent is the share of the interval randomly added to or removed from each wait, defaults to 10.
+	JitterPercent float64 `json:"jitterPercent"`
+	// Type is the kind of probe, http, tcp or grpc, defaults to http.
+	Type string `json:"type"`
+	// GRPCService is the service name sent in the gRPC health check requests, empty for the whole server.
+	GRPCService string `json:"grpcService"`
+	// Method is the HTTP method of the health check requests, defaults to GET.
+	Method string `json:"method"`
+	// Headers are set on the health check requests.
+	Headers map[string]string `json:"headers"`
+	// Host overrides the Host header of the health check requests.
+	Host string `json:"host"`
+	// ExpectedStatuses are the status codes, e.g. "204", or ranges, e.g. "200-299", of a healthy response, defaults to 200.
+	ExpectedStatuses []string `json:"expectedStatuses"`
+	// Body is a substring the body of a healthy response contains.
+	Body string `json:"body"`
+	// BodyRegex is a regular expression the body of a healthy response matches.
+	BodyRegex string `json:"bodyRegex"`
+	// Port is probed instead of the backend port, e.g. a separate management port.
+	Port int `json:"port"`
+}
+
+// OutlierDetection defines when the backends failing the proxied requests are ejected from rotation,
+// the fields left 0 take their default.
+type OutlierDetection struct {
+	// ConsecutiveErrors is the number of consecutive 5xx responses or proxy errors ejecting a backend, disabled when 0.
+	ConsecutiveErrors int `json:"consecutiveErrors"`
+	// ErrorRate is the percentage of failed requests in a window ejecting a backend, disabled when 0.
+	ErrorRate float64 `json:"errorRate"`
+	// Window is the length in seconds of the error rate window, defaults to 10.
+	Window int `json:"window"`
+	// MinRequests is the number of requests a window needs for its error rate to count, defaults to 20.
+	MinRequests int `json:"minRequests"`
+	// BaseEjectionTime is the ejection time in seconds, multiplied by the times the backend was ejected, defaults to 30.
+	BaseEjectionTime int `json:"baseEjectionTime"`
+	// MaxEjectionTime caps the ejection time in seconds, defaults to 300.
+	MaxEjectionTime int `json:"maxEjectionTime"`
+	// MaxEjectionPercent caps the share of the pool ejected at once, defaults to 10. One backend can always be ejected.
+	MaxEjectionPercent float64 `json:"maxEjectionPercent"`
+}
+
+// CircuitBreaker defines when the circuit breaker of a backend opens and how it closes again,
+// the fields left 0 take their default.
+type CircuitBreaker struct {
+	// FailureRate is the percentage of 5xx responses and proxy errors in a window opening the breaker, disabled when 0.
+	FailureRate float64 `json:"failureRate"`
+	// Window is the length in seconds of the failure rate window, defaults to 10.
+	Window int `json:"window"`
+	// MinRequests is the number of requests a window needs for its failure rate to count, defaults to 20.
+	MinRequests int `json:"minRequests"`
+	// ConsecutiveTimeouts is the number of backend timeouts in a row opening the breaker, disabled when 0.
+	ConsecutiveTimeouts int `json:"consecutiveTimeouts"`
+	// OpenTimeout is the time in seconds the breaker stays open before letting trial requests through, defaults to 30.
+	OpenTimeout int `json:"openTimeout"`
+	// HalfOpenRequests is the number of trial requests that must succeed to close the breaker, defaults to 3.
+	HalfOpenRequests int `json:"halfOpenRequests"`
 }
 
 // Endpoint defines the configuration for a single backend endpoint.
@@ -78,6 +291,22 @@ func init() {
 		// Push alert here
 		Logger.Fatal("Invalid port for load balancer in config file", zap.Error(err))
 	}
+
+	if err := validateAdminPort(Config.Server); err != nil {
+		// Push alert here
+		Logger.Fatal("Invalid admin port in config file", zap.Error(err))
+	}
+}
+
+// validateAdminPort checks the admin API port, when it is enabled, is free and apart from the load balancer port.
+func validateAdminPort(server Server) error {
+	if server.AdminPort == 0 {
+		return nil
+	}
+	if server.AdminPort == server.Port {
+		return errors.New("admin port must differ from the load balancer port")
+	}
+	return utils.ValidatePort(server.AdminPort)
 }
 
 // GracefulShutdownConfig Shutdown server gracefully on context cancellation
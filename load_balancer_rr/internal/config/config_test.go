@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -26,9 +27,11 @@ func TestInit_InvalidConfigFile(t *testing.T) {
 	// Reinitialize the package level variables
 	Logger = nil
 	Config = &struct {
-		Server                         Server  `json:"server"`
-		Backend                        Backend `json:"backend"`
-		HealthCheckTickerTimeInSeconds int64   `json:"healthCheckTickerTimeInSeconds"`
+		Server                         Server    `json:"server"`
+		Backend                        Backend   `json:"backend"`
+		Services                       []Service `json:"services"`
+		Rules                          []Rule    `json:"rules"`
+		HealthCheckTickerTimeInSeconds int64     `json:"healthCheckTickerTimeInSeconds"`
 	}{}
 
 	// Capture log output
@@ -51,3 +54,54 @@ func TestGracefulShutdownConfig(t *testing.T) {
 	// Give some time for the shutdown process to start
 	time.Sleep(1 * time.Second)
 }
+
+func TestValidateAdminPort(t *testing.T) {
+	require.NoError(t, validateAdminPort(Server{Port: 8082}))
+	require.NoError(t, validateAdminPort(Server{Port: 8082, AdminPort: 8083}))
+	require.EqualError(t, validateAdminPort(Server{Port: 8082, AdminPort: 8082}), "admin port must differ from the load balancer port")
+	require.Error(t, validateAdminPort(Server{Port: 8082, AdminPort: 70000}))
+}
+
+func TestRoute_UnmarshalJSON(t *testing.T) {
+	var backend Backend
+	err := json.Unmarshal([]byte(`{
+		"algorithm": "weighted_round_robin",
+		"routes": [
+			"http://localhost:8085",
+			{"url": "http://localhost:8086", "weight": 3}
+		]
+	}`), &backend)
+	require.NoError(t, err)
+
+	require.Equal(t, "weighted_round_robin", backend.Algorithm)
+	require.Equal(t, []Route{
+		{URL: "http://localhost:8085"},
+		{URL: "http://localhost:8086", Weight: 3},
+	}, backend.Routes)
+
+	err = json.Unmarshal([]byte(`{"routes": [42]}`), &backend)
+	require.Error(t, err)
+}
+
+func TestService_UnmarshalJSON(t *testing.T) {
+	var services []Service
+	err := json.Unmarshal([]byte(`[{
+		"name": "payments",
+		"pathPrefix": "/payments",
+		"host": "api.example.com",
+		"algorithm": "least_connections",
+		"routes": ["http://localhost:9085"],
+		"endpoints": {"healthcheck": {"url": "/health", "timeout": 2}},
+		"responseTimeout": 15
+	}]`), &services)
+	require.NoError(t, err)
+
+	require.Len(t, services, 1)
+	require.Equal(t, "payments", services[0].Name)
+	require.Equal(t, "/payments", services[0].PathPrefix)
+	require.Equal(t, "api.example.com", services[0].Host)
+	require.Equal(t, "least_connections", services[0].Algorithm)
+	require.Equal(t, []Route{{URL: "http://localhost:9085"}}, services[0].Routes)
+	require.Equal(t, Endpoint{URL: "/health", Timeout: 2}, services[0].Endpoint["healthcheck"])
+	require.Equal(t, 15, services[0].ResponseTimeout)
+}
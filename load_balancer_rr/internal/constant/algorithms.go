@@ -0,0 +1,20 @@
+package constant
+
+const (
+	// RoundRobin distributes requests equally across the backends in turn
+	RoundRobin = "round_robin"
+	// WeightedRoundRobin distributes requests in proportion to the backend weights
+	WeightedRoundRobin = "weighted_round_robin"
+	// LeastConnections sends requests to the backend with the fewest in-flight requests
+	LeastConnections = "least_connections"
+	// PeakEWMA picks the lower latency of two random backends (power of two choices)
+	PeakEWMA = "peak_ewma"
+	// ConsistentHash routes requests with the same key to the same backend
+	ConsistentHash = "consistent_hash"
+	// ConsistentHashBoundedLoad routes requests with the same key to the same backend unless it is overloaded
+	ConsistentHashBoundedLoad = "consistent_hash_bounded_load"
+	// Random picks an alive backend uniformly at random
+	Random = "random"
+	// WeightedRandom picks an alive backend at random in proportion to the backend weights
+	WeightedRandom = "weighted_random"
+)
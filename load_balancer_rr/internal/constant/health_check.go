@@ -0,0 +1,10 @@
+package constant
+
+const (
+	// HealthCheckHTTP probes the backends with an HTTP request to their health check endpoint
+	HealthCheckHTTP = "http"
+	// HealthCheckTCP probes the backends by opening a TCP connection
+	HealthCheckTCP = "tcp"
+	// HealthCheckGRPC probes the backends with the standard grpc.health.v1.Health/Check call
+	HealthCheckGRPC = "grpc"
+)
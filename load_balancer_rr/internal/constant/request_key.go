@@ -0,0 +1,12 @@
+package constant
+
+const (
+	// KeySourceHeader reads the request key from a header
+	KeySourceHeader = "header"
+	// KeySourceCookie reads the request key from a cookie
+	KeySourceCookie = "cookie"
+	// KeySourceQuery reads the request key from a query parameter
+	KeySourceQuery = "query"
+	// KeySourceIP uses the client IP as the request key
+	KeySourceIP = "ip"
+)
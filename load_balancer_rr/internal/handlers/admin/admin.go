@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/circuit_breaker"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/router"
+)
+
+// Admin serves the admin API used to inspect and change the load balancer at runtime.
+// It is served on its own listener so it is never exposed on the proxy port, and the
+// endpoints changing the load balancer require the admin token as a bearer token.
+type Admin struct {
+	router *router.Router
+	mux    *http.ServeMux
+	token  string
+}
+
+// CanaryStatus is the JSON representation of the canary split of a service.
+type CanaryStatus struct {
+	Service string  `json:"service"`
+	Canary  string  `json:"canary"`
+	Percent float64 `json:"percent"`
+}
+
+// CircuitBreakerStatus is the JSON representation of the circuit breaker of a backend.
+type CircuitBreakerStatus struct {
+	Service string `json:"service"`
+	URL     string `json:"url"`
+	State   string `json:"state"`
+}
+
+// canaryUpdate is the JSON body changing the canary split of a service.
+type canaryUpdate struct {
+	Percent *float64 `json:"percent"`
+}
+
+// New initializes and returns a new Admin for the routes of the router. The endpoints changing the
+// load balancer are refused when the token is empty.
+func New(rt *router.Router, token string) *Admin {
+	a := &Admin{router: rt, mux: http.NewServeMux(), token: token}
+	a.mux.HandleFunc("GET /admin/backends", a.listBackends)
+	a.mux.HandleFunc("POST /admin/services/{service}/backends", a.authorized(a.addBackend))
+	a.mux.HandleFunc("DELETE /admin/services/{service}/backends", a.authorized(a.removeBackend))
+	a.mux.HandleFunc("POST /admin/services/{service}/backends/drain", a.authorized(a.drainBackend))
+	a.mux.HandleFunc("POST /admin/services/{service}/backends/undrain", a.authorized(a.undrainBackend))
+	a.mux.HandleFunc("GET /admin/pools", a.listPools)
+	a.mux.HandleFunc("GET /admin/canaries", a.listCanaries)
+	a.mux.HandleFunc("PUT /admin/services/{service}/canary", a.authorized(a.updateCanary))
+	a.mux.HandleFunc("GET /admin/circuitbreakers", a.listCircuitBreakers)
+	return a
+}
+
+// ServeHTTP dispatches the admin API requests.
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mux.ServeHTTP(w, r)
+}
+
+// authorized only lets the requests carrying the admin token as a bearer token through to the handler.
+func (a *Admin) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.token == "" {
+			http.Error(w, "Admin token not configured", http.StatusForbidden)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// listCanaries responds with the canary split of every service that has one.
+func (a *Admin) listCanaries(w http.ResponseWriter, _ *http.Request) {
+	canaries := make([]CanaryStatus, 0)
+	for _, route := range a.router.Routes() {
+		if route.Canary != nil {
+			canaries = append(canaries, canaryStatus(route))
+		}
+	}
+	writeJSON(w, http.StatusOK, canaries)
+}
+
+// updateCanary changes the share of the requests of a service sent to its canary.
+func (a *Admin) updateCanary(w http.ResponseWriter, r *http.Request) {
+	route := a.router.Route(r.PathValue("service"))
+	if route == nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	if route.Canary == nil {
+		http.Error(w, "Service has no canary", http.StatusNotFound)
+		return
+	}
+
+	var update canaryUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update.Percent == nil {
+		http.Error(w, "Invalid canary update", http.StatusBadRequest)
+		return
+	}
+	if err := route.Canary.SetPercent(*update.Percent); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config.Logger.Info("updated canary split", zap.String("service", route.Service.Name),
+		zap.Float64("percent", route.Canary.Percent()))
+	writeJSON(w, http.StatusOK, canaryStatus(route))
+}
+
+// listCircuitBreakers responds with the state of the circuit breaker of every backend that has one.
+func (a *Admin) listCircuitBreakers(w http.ResponseWriter, _ *http.Request) {
+	breakers := make([]CircuitBreakerStatus, 0)
+	for _, route := range a.router.Routes() {
+		for _, b := range route.Pool.ListServiceBackends() {
+			if breaker, ok := b.(*circuit_breaker.CircuitBreaker); ok {
+				breakers = append(breakers, CircuitBreakerStatus{
+					Service: route.Service.Name,
+					URL:     breaker.GetURL().String(),
+					State:   breaker.State().String(),
+				})
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, breakers)
+}
+
+func canaryStatus(route *router.Route) CanaryStatus {
+	return CanaryStatus{
+		Service: route.Service.Name,
+		Canary:  route.Canary.Route().Service.Name,
+		Percent: route.Canary.Percent(),
+	}
+}
+
+// writeJSON responds with the value encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		config.Logger.Error("failed to write admin response", zap.Error(err))
+	}
+}
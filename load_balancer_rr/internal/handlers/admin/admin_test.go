@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/circuit_breaker"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/router"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+)
+
+const adminToken = "s3cr3t"
+
+func newCanaryRouter(t *testing.T) (*router.Router, *router.Route) {
+	rt := router.NewRouter()
+	game := &router.Route{Service: config.Service{Name: "game", PathPrefix: "/"}}
+	canaryRoute := &router.Route{Service: config.Service{Name: "game-canary", PathPrefix: "/canary"}}
+	canary, err := router.NewCanary(config.Canary{Service: "game-canary", Percent: 5}, canaryRoute)
+	assert.NoError(t, err)
+	game.Canary = canary
+	rt.AddRoute(game)
+	rt.AddRoute(canaryRoute)
+	return rt, game
+}
+
+func serve(a *Admin, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rr := httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdmin_ListCanaries(t *testing.T) {
+	rt, _ := newCanaryRouter(t)
+
+	rr := serve(New(rt, adminToken), http.MethodGet, "/admin/canaries", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var canaries []CanaryStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &canaries))
+	assert.Equal(t, []CanaryStatus{{Service: "game", Canary: "game-canary", Percent: 5}}, canaries)
+}
+
+func TestAdmin_UpdateCanary(t *testing.T) {
+	rt, game := newCanaryRouter(t)
+	a := New(rt, adminToken)
+
+	rr := serve(a, http.MethodPut, "/admin/services/game/canary", `{"percent": 25}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 25.0, game.Canary.Percent())
+
+	rr = serve(a, http.MethodPut, "/admin/services/game/canary", `{"percent": 250}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, 25.0, game.Canary.Percent())
+
+	rr = serve(a, http.MethodPut, "/admin/services/game/canary", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(a, http.MethodPut, "/admin/services/game-canary/canary", `{"percent": 25}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serve(a, http.MethodPut, "/admin/services/unknown/canary", `{"percent": 25}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Changing the split of live traffic requires the admin token
+	req := httptest.NewRequest(http.MethodPut, "/admin/services/game/canary", strings.NewReader(`{"percent": 100}`))
+	rr = httptest.NewRecorder()
+	a.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = serve(New(rt, ""), http.MethodPut, "/admin/services/game/canary", `{"percent": 100}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, 25.0, game.Canary.Percent())
+}
+
+func TestAdmin_Authorization(t *testing.T) {
+	rt, game := newCanaryRouter(t)
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		expected      int
+	}{
+		{name: "missing token", token: adminToken, expected: http.StatusUnauthorized},
+		{name: "wrong token", token: adminToken, authorization: "Bearer wrong", expected: http.StatusUnauthorized},
+		{name: "not a bearer token", token: adminToken, authorization: adminToken, expected: http.StatusUnauthorized},
+		{name: "token not configured", authorization: "Bearer ", expected: http.StatusForbidden},
+		{name: "valid token", token: adminToken, authorization: "Bearer " + adminToken, expected: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/admin/services/game/canary", strings.NewReader(`{"percent": 10}`))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+			New(rt, tt.token).ServeHTTP(rr, req)
+			assert.Equal(t, tt.expected, rr.Code)
+		})
+	}
+	assert.Equal(t, 10.0, game.Canary.Percent())
+
+	// Reading the state requires no token
+	req := httptest.NewRequest(http.MethodGet, "/admin/canaries", nil)
+	rr := httptest.NewRecorder()
+	New(rt, adminToken).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAdmin_ListCircuitBreakers(t *testing.T) {
+	u, _ := url.Parse("http://localhost:8080")
+	pool := round_robin.Initialize()
+	pool.RegisterServiceBackend(circuit_breaker.New("game", config.CircuitBreaker{FailureRate: 50}, backend.NewBackendServer(u, nil)))
+	pool.RegisterServiceBackend(backend.NewBackendServer(u, nil))
+
+	rt := router.NewRouter()
+	rt.AddRoute(&router.Route{Service: config.Service{Name: "game", PathPrefix: "/"}, Pool: pool})
+
+	rr := serve(New(rt, adminToken), http.MethodGet, "/admin/circuitbreakers", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var breakers []CircuitBreakerStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &breakers))
+	assert.Equal(t, []CircuitBreakerStatus{{Service: "game", URL: "http://localhost:8080", State: "closed"}}, breakers)
+}
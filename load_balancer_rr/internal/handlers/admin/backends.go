@@ -0,0 +1,213 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/constant"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/router"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+)
+
+// BackendStatus is the JSON representation of the state of a backend.
+type BackendStatus struct {
+	Service   string `json:"service"`
+	URL       string `json:"url"`
+	Alive     bool   `json:"alive"`
+	Available bool   `json:"available"`
+	// Pending is set for a backend added at runtime until its first successful health check
+	Pending bool `json:"pending"`
+	// Draining is set for a backend finishing its in-flight requests before its removal
+	Draining bool `json:"draining"`
+	// LastCheck is the time of the last health check, nil when the backend was never checked
+	LastCheck *time.Time `json:"lastCheck"`
+	LastError string     `json:"lastError,omitempty"`
+	InFlight  int64      `json:"inFlight"`
+	Weight    int        `json:"weight"`
+}
+
+// PoolStatus is the JSON summary of the pool of a service.
+type PoolStatus struct {
+	Service   string `json:"service"`
+	Algorithm string `json:"algorithm"`
+	Backends  int    `json:"backends"`
+	Alive     int    `json:"alive"`
+	Available int    `json:"available"`
+	InFlight  int64  `json:"inFlight"`
+}
+
+// listBackends responds with the state of every backend of every service.
+func (a *Admin) listBackends(w http.ResponseWriter, _ *http.Request) {
+	backends := make([]BackendStatus, 0)
+	for _, route := range a.router.Routes() {
+		for _, b := range route.Pool.ListServiceBackends() {
+			backends = append(backends, backendStatus(route, b))
+		}
+	}
+	writeJSON(w, http.StatusOK, backends)
+}
+
+// addBackend builds a backend from the route in the body and registers it in the pool of a service.
+// The backend gets no traffic until its first successful health check.
+func (a *Admin) addBackend(w http.ResponseWriter, r *http.Request) {
+	route := a.router.Route(r.PathValue("service"))
+	if route == nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	if route.NewBackend == nil {
+		http.Error(w, "Service does not support adding backends", http.StatusConflict)
+		return
+	}
+
+	var backendRoute config.Route
+	if err := json.NewDecoder(r.Body).Decode(&backendRoute); err != nil {
+		http.Error(w, "Invalid backend", http.StatusBadRequest)
+		return
+	}
+	if err := serverpool.ValidateRoute(route.Service.Backend, backendRoute); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b, err := route.NewBackend(backendRoute)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !route.RegisterBackend(b) {
+		http.Error(w, "Backend already registered", http.StatusConflict)
+		return
+	}
+
+	config.Logger.Info("added server", zap.String("service", route.Service.Name), zap.String("host: ", b.GetURL().Host),
+		zap.Int("weight", b.GetWeight()), zap.Int("priority", b.GetPriority()), zap.String("zone", b.GetZone()))
+	writeJSON(w, http.StatusCreated, backendStatus(route, b))
+}
+
+// removeBackend removes the backend with the url query parameter from the pool of a service right away.
+// Its in-flight requests keep going through its proxy, but nothing tells when they are over and the backend
+// is safe to shut down, drainBackend does.
+func (a *Admin) removeBackend(w http.ResponseWriter, r *http.Request) {
+	route := a.router.Route(r.PathValue("service"))
+	if route == nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	b := findBackend(route, r.URL.Query().Get("url"))
+	if b == nil {
+		http.Error(w, "Backend not found", http.StatusNotFound)
+		return
+	}
+
+	route.Pool.RemoveBackend(b)
+	config.Logger.Info("removed server", zap.String("service", route.Service.Name), zap.String("host: ", b.GetURL().Host))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// drainBackend stops sending new requests to the backend with the url query parameter, and removes it from
+// the pool of the service in the background once its in-flight requests finish or the drain timeout runs out.
+// With the keep query parameter set to true the backend stays in the pool, out of rotation, for maintenance.
+func (a *Admin) drainBackend(w http.ResponseWriter, r *http.Request) {
+	route := a.router.Route(r.PathValue("service"))
+	if route == nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	b := findBackend(route, r.URL.Query().Get("url"))
+	if b == nil {
+		http.Error(w, "Backend not found", http.StatusNotFound)
+		return
+	}
+	if !b.Drain() {
+		http.Error(w, "Backend already draining", http.StatusConflict)
+		return
+	}
+
+	keep := r.URL.Query().Get("keep") == "true"
+	config.Logger.Info("draining server", zap.String("service", route.Service.Name), zap.String("host: ", b.GetURL().Host),
+		zap.Int64("inFlight", b.GetActiveConnections()), zap.Bool("keep", keep))
+	if !keep {
+		go serverpool.Drain(route.Pool, b, time.Duration(route.Service.DrainTimeout)*time.Second)
+	}
+	writeJSON(w, http.StatusAccepted, backendStatus(route, b))
+}
+
+// undrainBackend puts the draining backend with the url query parameter back in rotation, after maintenance
+// or to cancel its drain before it is removed from the pool of the service.
+func (a *Admin) undrainBackend(w http.ResponseWriter, r *http.Request) {
+	route := a.router.Route(r.PathValue("service"))
+	if route == nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	b := findBackend(route, r.URL.Query().Get("url"))
+	if b == nil {
+		http.Error(w, "Backend not found", http.StatusNotFound)
+		return
+	}
+	if !b.Undrain() {
+		http.Error(w, "Backend not draining", http.StatusConflict)
+		return
+	}
+
+	config.Logger.Info("undrained server", zap.String("service", route.Service.Name), zap.String("host: ", b.GetURL().Host))
+	writeJSON(w, http.StatusOK, backendStatus(route, b))
+}
+
+// listPools responds with the summary of the pool of every service.
+func (a *Admin) listPools(w http.ResponseWriter, _ *http.Request) {
+	pools := make([]PoolStatus, 0)
+	for _, route := range a.router.Routes() {
+		pool := PoolStatus{Service: route.Service.Name, Algorithm: route.Service.Algorithm}
+		if pool.Algorithm == "" {
+			pool.Algorithm = constant.RoundRobin
+		}
+		for _, b := range route.Pool.ListServiceBackends() {
+			pool.Backends++
+			if b.IsAlive() {
+				pool.Alive++
+			}
+			if b.IsAvailable() {
+				pool.Available++
+			}
+			pool.InFlight += b.GetActiveConnections()
+		}
+		pools = append(pools, pool)
+	}
+	writeJSON(w, http.StatusOK, pools)
+}
+
+func backendStatus(route *router.Route, b backend.Backend) BackendStatus {
+	status := BackendStatus{
+		Service:   route.Service.Name,
+		URL:       b.GetURL().String(),
+		Alive:     b.IsAlive(),
+		Available: b.IsAvailable(),
+		Pending:   b.IsPending(),
+		Draining:  b.IsDraining(),
+		InFlight:  b.GetActiveConnections(),
+		Weight:    b.GetWeight(),
+	}
+	if checked, err := b.GetLastHealthCheck(); !checked.IsZero() {
+		status.LastCheck = &checked
+		if err != nil {
+			status.LastError = err.Error()
+		}
+	}
+	return status
+}
+
+// findBackend returns the backend of the route pool with the URL, or nil when there is none.
+func findBackend(route *router.Route, rawURL string) backend.Backend {
+	for _, b := range route.Pool.ListServiceBackends() {
+		if b.GetURL().String() == rawURL {
+			return b
+		}
+	}
+	return nil
+}
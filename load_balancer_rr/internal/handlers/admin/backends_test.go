@@ -0,0 +1,278 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/router"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/least_connections"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+)
+
+// newStatusRouter returns a router with a game service of two backends, one of them dead, and an empty auth service.
+func newStatusRouter() *router.Router {
+	first, _ := url.Parse("http://localhost:8085")
+	second, _ := url.Parse("http://localhost:8086")
+
+	healthy := backend.NewBackendServer(first, nil, backend.WithWeight(3))
+	healthy.RecordHealthCheck(nil)
+	dead := backend.NewBackendServer(second, nil)
+	dead.RecordHealthCheck(errors.New("unexpected health check status 500"))
+	dead.SetAlive(false)
+
+	game := round_robin.Initialize()
+	game.RegisterServiceBackend(healthy)
+	game.RegisterServiceBackend(dead)
+
+	rt := router.NewRouter()
+	rt.AddRoute(&router.Route{Service: config.Service{Name: "game", PathPrefix: "/"}, Pool: game})
+	rt.AddRoute(&router.Route{
+		Service: config.Service{Name: "auth", Host: "auth.example.com", Backend: config.Backend{Algorithm: "least_connections"}},
+		Pool:    least_connections.Initialize(),
+	})
+	return rt
+}
+
+func TestAdmin_ListBackends(t *testing.T) {
+	rr := serve(New(newStatusRouter(), adminToken), http.MethodGet, "/admin/backends", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var backends []BackendStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &backends))
+	assert.Len(t, backends, 2)
+
+	assert.Equal(t, "game", backends[0].Service)
+	assert.Equal(t, "http://localhost:8085", backends[0].URL)
+	assert.True(t, backends[0].Alive)
+	assert.True(t, backends[0].Available)
+	assert.NotNil(t, backends[0].LastCheck)
+	assert.Empty(t, backends[0].LastError)
+	assert.Equal(t, 3, backends[0].Weight)
+
+	assert.Equal(t, "http://localhost:8086", backends[1].URL)
+	assert.False(t, backends[1].Alive)
+	assert.False(t, backends[1].Available)
+	assert.Equal(t, "unexpected health check status 500", backends[1].LastError)
+	assert.Equal(t, int64(0), backends[1].InFlight)
+}
+
+func TestAdmin_ListPools(t *testing.T) {
+	rr := serve(New(newStatusRouter(), adminToken), http.MethodGet, "/admin/pools", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var pools []PoolStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &pools))
+	assert.ElementsMatch(t, []PoolStatus{
+		{Service: "game", Algorithm: "round_robin", Backends: 2, Alive: 1, Available: 1},
+		{Service: "auth", Algorithm: "least_connections"},
+	}, pools)
+}
+
+func TestAdmin_NotFound(t *testing.T) {
+	rr := serve(New(newStatusRouter(), adminToken), http.MethodGet, "/admin/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serve(New(newStatusRouter(), adminToken), http.MethodPost, "/admin/backends", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+// newBackendFunc builds pending backends without a proxy, as the routes of the server do.
+func newBackendFunc(route config.Route) (backend.Backend, error) {
+	u, err := url.Parse(route.URL)
+	if err != nil {
+		return nil, err
+	}
+	return backend.NewBackendServer(u, nil, backend.WithWeight(route.Weight), backend.WithPending()), nil
+}
+
+func TestAdmin_AddBackend(t *testing.T) {
+	rt := newStatusRouter()
+	game := rt.Route("game")
+	game.NewBackend = newBackendFunc
+	a := New(rt, adminToken)
+
+	rr := serve(a, http.MethodPost, "/admin/services/game/backends", `{"url": "http://localhost:8087", "weight": 2}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var status BackendStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.Equal(t, BackendStatus{Service: "game", URL: "http://localhost:8087", Pending: true, Weight: 2}, status)
+	assert.Equal(t, int32(3), game.Pool.GetServerPoolSize())
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends", `{"url": "http://localhost:8087"}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends", `{"url": ":invalid"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends", `not json`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The pool of the game service has no priority tiers nor zones to honour
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends", `{"url": "http://localhost:8088", "priority": 1}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends", `{"url": "http://localhost:8088", "zone": "ap-southeast-1b"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/auth/backends", `{"url": "http://localhost:8088"}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/unknown/backends", `{"url": "http://localhost:8088"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, int32(3), game.Pool.GetServerPoolSize())
+}
+
+// slowListPool widens the window between looking a backend up in the pool and registering it.
+type slowListPool struct {
+	*round_robin.RoundRobin
+}
+
+func (p slowListPool) ListServiceBackends() []backend.Backend {
+	backends := p.RoundRobin.ListServiceBackends()
+	time.Sleep(10 * time.Millisecond)
+	return backends
+}
+
+func TestAdmin_AddBackend_Concurrent(t *testing.T) {
+	rt := router.NewRouter()
+	game := &router.Route{
+		Service:    config.Service{Name: "game", PathPrefix: "/"},
+		Pool:       slowListPool{round_robin.Initialize()},
+		NewBackend: newBackendFunc,
+	}
+	rt.AddRoute(game)
+	a := New(rt, adminToken)
+
+	// Concurrent requests adding the same backend register it once
+	codes := make(chan int, 16)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- serve(a, http.MethodPost, "/admin/services/game/backends", `{"url": "http://localhost:8087"}`).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: cap(codes) - 1}, counts)
+	assert.Equal(t, int32(1), game.Pool.GetServerPoolSize())
+}
+
+func TestAdmin_RemoveBackend(t *testing.T) {
+	rt := newStatusRouter()
+	a := New(rt, adminToken)
+
+	rr := serve(a, http.MethodDelete, "/admin/services/game/backends?url=http://localhost:8086", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	backends := rt.Route("game").Pool.ListServiceBackends()
+	assert.Len(t, backends, 1)
+	assert.Equal(t, "http://localhost:8085", backends[0].GetURL().String())
+
+	rr = serve(a, http.MethodDelete, "/admin/services/game/backends?url=http://localhost:8086", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serve(a, http.MethodDelete, "/admin/services/unknown/backends?url=http://localhost:8085", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAdmin_DrainBackend(t *testing.T) {
+	// The game backend serves a request until it is released
+	started, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	busy := backend.NewBackendServer(u, httputil.NewSingleHostReverseProxy(u))
+
+	rt := newStatusRouter()
+	game := rt.Route("game")
+	game.Pool.RegisterServiceBackend(busy)
+	go busy.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	<-started
+	a := New(rt, adminToken)
+
+	rr := serve(a, http.MethodPost, "/admin/services/game/backends/drain?url="+server.URL, "")
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	var status BackendStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.True(t, status.Draining)
+	assert.False(t, status.Available)
+	assert.Equal(t, int64(1), status.InFlight)
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends/drain?url="+server.URL, "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// The backend stays in the pool until its request finishes
+	time.Sleep(3 * serverpool.DrainPollInterval)
+	assert.Equal(t, int32(3), game.Pool.GetServerPoolSize())
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return game.Pool.GetServerPoolSize() == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Nil(t, findBackend(game, server.URL))
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends/drain?url=http://localhost:8087", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/unknown/backends/drain?url=http://localhost:8085", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAdmin_MaintenanceBackend(t *testing.T) {
+	rt := newStatusRouter()
+	game := rt.Route("game")
+	a := New(rt, adminToken)
+
+	rr := serve(a, http.MethodPost, "/admin/services/game/backends/drain?keep=true&url=http://localhost:8085", "")
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var status BackendStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.True(t, status.Draining)
+	assert.False(t, status.Available)
+
+	// The backend stays in the pool, out of rotation, until it is undrained
+	time.Sleep(3 * serverpool.DrainPollInterval)
+	assert.Equal(t, int32(2), game.Pool.GetServerPoolSize())
+	assert.Nil(t, game.Pool.NextAvailableBackend())
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends/undrain?url=http://localhost:8085", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.False(t, status.Draining)
+	assert.True(t, status.Available)
+	assert.Equal(t, "http://localhost:8085", game.Pool.NextAvailableBackend().GetURL().String())
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends/undrain?url=http://localhost:8085", "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/game/backends/undrain?url=http://localhost:8087", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serve(a, http.MethodPost, "/admin/services/unknown/backends/undrain?url=http://localhost:8085", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
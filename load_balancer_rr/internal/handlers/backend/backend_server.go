@@ -1,45 +1,170 @@
 package backend
 
 import (
+	"math"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"sync/atomic"
+	"time"
 )
 
+// MinSlowStartFactor is the share of its weight a backendServer starts with during slow start.
+const MinSlowStartFactor = 0.1
+
 // backendServer represents a single backendServer server with its URL and state.
 type backendServer struct {
 	url          *url.URL
 	alive        atomic.Bool
 	reverseProxy *httputil.ReverseProxy
+	weight       int
+	priority     int
+	zone         string
+	// slowStart is the window over which the effective weight ramps up after the backend becomes alive
+	slowStart time.Duration
+	// aliveSince is the unix nano time the backendServer was added or last recovered
+	aliveSince atomic.Int64
+	// activeConnections counts the requests currently being proxied to the backendServer
+	activeConnections atomic.Int64
+	latency           latencyTracker
+	health            healthHistory
+	// ejectedUntil is the unix nano time the outlier ejection of the backendServer ends, 0 when never ejected
+	ejectedUntil atomic.Int64
+	// pending is set until the first time the backendServer is marked alive, for backends registered at runtime
+	pending atomic.Bool
+	// draining is set while the backendServer takes no new requests, before its removal or for maintenance
+	draining atomic.Bool
+}
+
+// Option configures optional attributes of a backendServer.
+type Option func(*backendServer)
+
+// WithWeight sets the relative weight of the backendServer, non-positive weights are ignored.
+func WithWeight(weight int) Option {
+	return func(b *backendServer) {
+		if weight > 0 {
+			b.weight = weight
+		}
+	}
 }
 
 // Backend interface defines methods for interacting with a backendServer server.
 type Backend interface {
 	Serve(http.ResponseWriter, *http.Request)
 	GetURL() *url.URL
-	SetAlive(atomic.Bool)
-	IsAlive() atomic.Bool
+	SetAlive(alive bool)
+	IsAlive() bool
+	IsAvailable() bool
+	Eject(until time.Time)
+	IsEjected() bool
+	IsPending() bool
+	Drain() bool
+	Undrain() bool
+	IsDraining() bool
+	GetWeight() int
+	GetEffectiveWeight() float64
+	GetPriority() int
+	GetZone() string
+	GetActiveConnections() int64
+	GetLatencyEWMA() time.Duration
+	RecordHealthCheck(err error) (successes int, failures int)
+	GetHealthHistory() (successes int, failures int)
+	GetLastHealthCheck() (time.Time, error)
+}
+
+// WithPriority sets the failover tier of the backendServer, 0 being the primary tier.
+func WithPriority(priority int) Option {
+	return func(b *backendServer) {
+		b.priority = priority
+	}
+}
+
+// WithZone sets the availability zone of the backendServer.
+func WithZone(zone string) Option {
+	return func(b *backendServer) {
+		b.zone = zone
+	}
+}
+
+// WithSlowStart sets the window over which the effective weight of a new or recovered backendServer
+// ramps up linearly to its full weight.
+func WithSlowStart(window time.Duration) Option {
+	return func(b *backendServer) {
+		b.slowStart = window
+	}
+}
+
+// WithPending starts the backendServer dead and pending until its first successful health check,
+// so a backend registered at runtime gets no traffic before it is known to be healthy.
+func WithPending() Option {
+	return func(b *backendServer) {
+		b.pending.Store(true)
+	}
 }
 
 // NewBackendServer initializes and returns a new backendServer instance.
-func NewBackendServer(u *url.URL, rp *httputil.ReverseProxy) Backend {
+func NewBackendServer(u *url.URL, rp *httputil.ReverseProxy, opts ...Option) Backend {
 	server := &backendServer{
 		url:          u,
 		reverseProxy: rp,
+		weight:       1,
+	}
+	for _, opt := range opts {
+		opt(server)
 	}
-	server.alive.Store(true)
+	server.alive.Store(!server.pending.Load())
+	server.aliveSince.Store(time.Now().UnixNano())
 	return server
 }
 
 // SetAlive updates the alive state of the backendServer server.
-func (b *backendServer) SetAlive(alive atomic.Bool) {
-	b.alive = alive
+// A backendServer recovering from dead restarts its slow start window, and is no longer pending.
+func (b *backendServer) SetAlive(alive bool) {
+	if !b.alive.Swap(alive) && alive {
+		b.aliveSince.Store(time.Now().UnixNano())
+		b.pending.Store(false)
+	}
+}
+
+// IsPending checks if the backendServer server is waiting for its first successful health check.
+func (b *backendServer) IsPending() bool {
+	return b.pending.Load()
 }
 
 // IsAlive checks if the backendServer server is alive.
-func (b *backendServer) IsAlive() atomic.Bool {
-	return b.alive
+func (b *backendServer) IsAlive() bool {
+	return b.alive.Load()
+}
+
+// IsAvailable checks if the backendServer server can take new requests: it is alive, not ejected and not draining.
+func (b *backendServer) IsAvailable() bool {
+	return b.alive.Load() && !b.IsEjected() && !b.draining.Load()
+}
+
+// Drain stops sending new requests to the backendServer server until Undrain, while its in-flight requests finish.
+// It reports false when the backendServer was already draining.
+func (b *backendServer) Drain() bool {
+	return !b.draining.Swap(true)
+}
+
+// Undrain puts the draining backendServer server back in rotation, it reports false when it was not draining.
+func (b *backendServer) Undrain() bool {
+	return b.draining.Swap(false)
+}
+
+// IsDraining checks if the backendServer server is draining, before its removal or for maintenance.
+func (b *backendServer) IsDraining() bool {
+	return b.draining.Load()
+}
+
+// Eject takes the backendServer server out of rotation until the given time, while it keeps being health checked.
+func (b *backendServer) Eject(until time.Time) {
+	b.ejectedUntil.Store(until.UnixNano())
+}
+
+// IsEjected checks if the backendServer server is currently ejected as an outlier.
+func (b *backendServer) IsEjected() bool {
+	return time.Now().UnixNano() < b.ejectedUntil.Load()
 }
 
 // GetURL retrieves the URL of the backendServer server.
@@ -47,8 +172,75 @@ func (b *backendServer) GetURL() *url.URL {
 	return b.url
 }
 
+// GetWeight retrieves the relative weight of the backendServer server.
+func (b *backendServer) GetWeight() int {
+	return b.weight
+}
+
+// GetEffectiveWeight retrieves the weight of the backendServer server adjusted for slow start:
+// during the slow start window it ramps linearly from MinSlowStartFactor of the weight to the full weight.
+func (b *backendServer) GetEffectiveWeight() float64 {
+	weight := float64(b.weight)
+	if b.slowStart <= 0 {
+		return weight
+	}
+
+	elapsed := time.Duration(time.Now().UnixNano() - b.aliveSince.Load())
+	if elapsed >= b.slowStart {
+		return weight
+	}
+	return weight * math.Max(MinSlowStartFactor, float64(elapsed)/float64(b.slowStart))
+}
+
+// GetPriority retrieves the failover tier of the backendServer server.
+func (b *backendServer) GetPriority() int {
+	return b.priority
+}
+
+// GetZone retrieves the availability zone of the backendServer server.
+func (b *backendServer) GetZone() string {
+	return b.zone
+}
+
+// GetActiveConnections retrieves the number of in-flight requests on the backendServer server.
+func (b *backendServer) GetActiveConnections() int64 {
+	return b.activeConnections.Load()
+}
+
+// GetLatencyEWMA retrieves the peak moving average of the backendServer response time,
+// including the time spent by requests that are still in flight.
+func (b *backendServer) GetLatencyEWMA() time.Duration {
+	return b.latency.score(time.Now())
+}
+
+// RecordHealthCheck adds the result of a health check, nil when it succeeded, to the history of the
+// backendServer server and returns its consecutive successful and failed health checks.
+func (b *backendServer) RecordHealthCheck(err error) (successes int, failures int) {
+	return b.health.record(err, time.Now())
+}
+
+// GetHealthHistory retrieves the consecutive successful and failed health checks of the backendServer server.
+func (b *backendServer) GetHealthHistory() (successes int, failures int) {
+	return b.health.counts()
+}
+
+// GetLastHealthCheck retrieves the time and error of the last health check of the backendServer server,
+// the zero time when it was never checked.
+func (b *backendServer) GetLastHealthCheck() (time.Time, error) {
+	return b.health.last()
+}
+
 // Serve handles incoming HTTP requests and forwards them to the backendServer server.
 func (b *backendServer) Serve(rw http.ResponseWriter, req *http.Request) {
+	// Track the request as in-flight until the proxied response completes
+	started := time.Now()
+	b.activeConnections.Add(1)
+	b.latency.start(started)
+	defer func() {
+		b.activeConnections.Add(-1)
+		b.latency.finish(started, time.Now())
+	}()
+
 	//push an alert here to check how many request we are triggering to each instance
 	// Proxy the request to the backendServer server
 	b.reverseProxy.ServeHTTP(rw, req)
@@ -2,56 +2,194 @@ package backend
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
-	"sync/atomic"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/coda-payments/load_balancer_rr/internal/config"
 	"github.com/coda-payments/load_balancer_rr/internal/constant"
 )
 
-// IsServerAlive checks if the server is alive by sending an HTTP GET request
-// to the server health check endpoint.
-func IsServerAlive(ctx context.Context, isAliveChannel chan atomic.Bool, url *url.URL) {
-	// Create a new HTTP client with a timeout
-	client := &http.Client{
-		Timeout: time.Duration(config.Config.Backend.Endpoint[constant.Healthcheck].Timeout) * time.Second, // Set a timeout for the request
+// maxHealthCheckBodyBytes is the part of the health check response body matched against the expected body.
+const maxHealthCheckBodyBytes = 64 << 10
+
+// Probe checks whether a backend server is alive.
+type Probe interface {
+	// Check returns nil when the server is alive, and the reason it is not otherwise.
+	Check(ctx context.Context, u *url.URL) error
+}
+
+// statusRange is an inclusive range of HTTP status codes.
+type statusRange struct {
+	from int
+	to   int
+}
+
+// httpProbe checks if the server is alive by sending an HTTP request to the server health check endpoint.
+type httpProbe struct {
+	client   *http.Client
+	path     string
+	method   string
+	headers  map[string]string
+	host     string
+	statuses []statusRange
+	body     string
+	bodyRe   *regexp.Regexp
+	port     int
+}
+
+// NewProbe returns the Probe of the backends of a service, configured by its health check endpoint and definition.
+func NewProbe(backendConfig config.Backend) (Probe, error) {
+	endpoint := backendConfig.Endpoint[constant.Healthcheck]
+	healthCheck := backendConfig.HealthCheck
+
+	switch healthCheck.Type {
+	case "", constant.HealthCheckHTTP:
+		return newHTTPProbe(endpoint, healthCheck)
+	case constant.HealthCheckTCP:
+		return newTCPProbe(endpoint, healthCheck), nil
+	case constant.HealthCheckGRPC:
+		return newGRPCProbe(endpoint, healthCheck), nil
+	default:
+		return nil, fmt.Errorf("unknown health check type %q", healthCheck.Type)
 	}
+}
 
-	urlString := url.String() + config.Config.Backend.Endpoint[constant.Healthcheck].URL
+// newHTTPProbe returns the probe sending the configured HTTP request to the health check endpoint.
+func newHTTPProbe(endpoint config.Endpoint, healthCheck config.HealthCheck) (*httpProbe, error) {
+	probe := &httpProbe{
+		client: &http.Client{
+			Timeout: time.Duration(endpoint.Timeout) * time.Second, // Set a timeout for the request
+			// Open a new connection for each check, so a probe never succeeds on a connection opened earlier
+			Transport: &http.Transport{DisableKeepAlives: true},
+		},
+		path:    endpoint.URL,
+		method:  healthCheck.Method,
+		headers: healthCheck.Headers,
+		host:    healthCheck.Host,
+		body:    healthCheck.Body,
+		port:    healthCheck.Port,
+	}
+	if probe.method == "" {
+		probe.method = http.MethodGet
+	}
+
+	statuses, err := parseStatuses(healthCheck.ExpectedStatuses)
+	if err != nil {
+		return nil, err
+	}
+	probe.statuses = statuses
+
+	if healthCheck.BodyRegex != "" {
+		bodyRe, regexErr := regexp.Compile(healthCheck.BodyRegex)
+		if regexErr != nil {
+			return nil, fmt.Errorf("invalid health check body regex %q: %w", healthCheck.BodyRegex, regexErr)
+		}
+		probe.bodyRe = bodyRe
+	}
+	return probe, nil
+}
+
+// Check sends the health check request to the server and verifies its response.
+func (p *httpProbe) Check(ctx context.Context, u *url.URL) error {
+	target := *u
+	if p.port != 0 {
+		target.Host = probeAddress(u, p.port)
+	}
+	urlString := target.String() + p.path
 
 	// Create the HTTP request to the health check endpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlString, nil)
+	req, err := http.NewRequestWithContext(ctx, p.method, urlString, nil)
 	if err != nil {
 		//push a metrics
-		isAliveChannel <- getAliveStatus(false)
-		return
+		return err
+	}
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+	if p.host != "" {
+		req.Host = p.host
 	}
 
 	// Perform the HTTP request
-	resp, err := client.Do(req)
+	resp, err := p.client.Do(req)
 	if err != nil {
 		// If there's an error, the server is not alive
 		//push a metrics
-		isAliveChannel <- getAliveStatus(false)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
 	// Check the status code received from healthcheck API to determine if the server is alive
-	var aliveStatus bool
-	if resp.StatusCode == http.StatusOK {
-		aliveStatus = true
-	} else {
-		aliveStatus = false
+	if !p.expectedStatus(resp.StatusCode) {
+		return fmt.Errorf("unexpected health check status %d", resp.StatusCode)
+	}
+
+	if p.body == "" && p.bodyRe == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHealthCheckBodyBytes))
+	if err != nil {
+		return err
+	}
+	if p.body != "" && !strings.Contains(string(body), p.body) {
+		return fmt.Errorf("health check body does not contain %q", p.body)
+	}
+	if p.bodyRe != nil && !p.bodyRe.Match(body) {
+		return fmt.Errorf("health check body does not match %q", p.bodyRe.String())
 	}
-	isAliveChannel <- getAliveStatus(aliveStatus)
+	return nil
 }
 
-// getAliveStatus returns an atomic.Bool representing the alive status of the server
-func getAliveStatus(status bool) atomic.Bool {
-	aliveStatus := atomic.Bool{}
-	aliveStatus.Store(status)
-	return aliveStatus
+// expectedStatus reports whether the status is one of a healthy response.
+func (p *httpProbe) expectedStatus(status int) bool {
+	for _, sr := range p.statuses {
+		if status >= sr.from && status <= sr.to {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatuses parses the expected status codes and ranges, defaulting to 200 when there are none.
+func parseStatuses(expected []string) ([]statusRange, error) {
+	if len(expected) == 0 {
+		return []statusRange{{from: http.StatusOK, to: http.StatusOK}}, nil
+	}
+
+	statuses := make([]statusRange, 0, len(expected))
+	for _, value := range expected {
+		from, to, isRange := strings.Cut(value, "-")
+		if !isRange {
+			to = from
+		}
+		fromStatus, fromErr := strconv.Atoi(strings.TrimSpace(from))
+		toStatus, toErr := strconv.Atoi(strings.TrimSpace(to))
+		if fromErr != nil || toErr != nil || fromStatus < 100 || toStatus > 599 || fromStatus > toStatus {
+			return nil, fmt.Errorf("invalid health check expected status %q", value)
+		}
+		statuses = append(statuses, statusRange{from: fromStatus, to: toStatus})
+	}
+	return statuses, nil
+}
+
+// probeAddress returns the host and port probed on the server, the port of the URL or of its scheme
+// unless an alternate port is set.
+func probeAddress(u *url.URL, port int) string {
+	if port != 0 {
+		return net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
 }
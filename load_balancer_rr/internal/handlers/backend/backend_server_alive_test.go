@@ -5,11 +5,24 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-	"sync/atomic"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
 )
 
+func newProbe(t *testing.T, healthCheck config.HealthCheck) Probe {
+	probe, err := NewProbe(config.Backend{
+		Endpoint:    map[string]config.Endpoint{"healthcheck": {URL: "/healthcheck", Timeout: 2}},
+		HealthCheck: healthCheck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create probe: %v", err)
+	}
+	return probe
+}
+
 func TestIsServerAlive(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -52,20 +65,112 @@ func TestIsServerAlive(t *testing.T) {
 				t.Fatalf("Failed to parse URL: %v", err)
 			}
 
-			isAliveChannel := make(chan atomic.Bool)
+			err = newProbe(t, config.HealthCheck{}).Check(context.Background(), serverURL)
+			if (err == nil) != tt.expectedStatus {
+				t.Errorf("Expected server alive status to be %v, got error %v", tt.expectedStatus, err)
+			}
+		})
+	}
+}
+
+func TestIsServerAlive_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer mockServer.Close()
+	defer close(release)
 
-			// Run IsServerAlive in a separate goroutine
-			go IsServerAlive(context.Background(), isAliveChannel, serverURL)
+	serverURL, _ := url.Parse(mockServer.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
 
-			// Wait for the result
-			select {
-			case status := <-isAliveChannel:
-				if status.Load() != tt.expectedStatus {
-					t.Errorf("Expected server alive status to be %v, got %v", tt.expectedStatus, status.Load())
-				}
-			case <-time.After(3 * time.Second): // Increased timeout for slower tests
-				t.Fatal("Test timed out waiting for response")
+	started := time.Now()
+	if err := newProbe(t, config.HealthCheck{}).Check(ctx, serverURL); err == nil {
+		t.Error("Expected a hanging server not to be alive")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the probe to stop with its context, took %v", elapsed)
+	}
+}
+
+func TestNewProbe_Invalid(t *testing.T) {
+	invalid := []config.HealthCheck{
+		{ExpectedStatuses: []string{"ok"}},
+		{ExpectedStatuses: []string{"299-200"}},
+		{ExpectedStatuses: []string{"200-700"}},
+		{BodyRegex: "("},
+	}
+	for _, healthCheck := range invalid {
+		if _, err := NewProbe(config.Backend{HealthCheck: healthCheck}); err == nil {
+			t.Errorf("Expected an error for %+v", healthCheck)
+		}
+	}
+}
+
+func TestHTTPProbe_Definition(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthcheck" || r.Header.Get("X-Probe") != "lb" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Host == "game.internal" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"UP","db":"connected"}`))
+	}))
+	defer mockServer.Close()
+
+	serverURL, _ := url.Parse(mockServer.URL)
+	headers := map[string]string{"X-Probe": "lb"}
+
+	tests := []struct {
+		name        string
+		healthCheck config.HealthCheck
+		alive       bool
+	}{
+		{name: "missing header", healthCheck: config.HealthCheck{}, alive: false},
+		{name: "header", healthCheck: config.HealthCheck{Headers: headers}, alive: true},
+		{name: "method without expected status", healthCheck: config.HealthCheck{Method: http.MethodHead, Headers: headers}, alive: false},
+		{name: "method with expected status", healthCheck: config.HealthCheck{Method: http.MethodHead, Headers: headers, ExpectedStatuses: []string{"200", "204"}}, alive: true},
+		{name: "expected status range", healthCheck: config.HealthCheck{Method: http.MethodHead, Headers: headers, ExpectedStatuses: []string{"200-299"}}, alive: true},
+		{name: "host override", healthCheck: config.HealthCheck{Headers: headers, Host: "game.internal"}, alive: false},
+		{name: "body substring", healthCheck: config.HealthCheck{Headers: headers, Body: `"status":"UP"`}, alive: true},
+		{name: "body substring missing", healthCheck: config.HealthCheck{Headers: headers, Body: `"status":"DOWN"`}, alive: false},
+		{name: "body regex", healthCheck: config.HealthCheck{Headers: headers, BodyRegex: `"db":"(connected|degraded)"`}, alive: true},
+		{name: "body regex mismatch", healthCheck: config.HealthCheck{Headers: headers, BodyRegex: `"db":"down"`}, alive: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newProbe(t, tt.healthCheck).Check(context.Background(), serverURL)
+			if (err == nil) != tt.alive {
+				t.Errorf("Expected server alive status to be %v, got error %v", tt.alive, err)
 			}
 		})
 	}
 }
+
+func TestHTTPProbe_Port(t *testing.T) {
+	management := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer management.Close()
+	managementURL, _ := url.Parse(management.URL)
+	port, _ := strconv.Atoi(managementURL.Port())
+
+	// The backend port itself does not answer
+	backendURL, _ := url.Parse("http://127.0.0.1:1")
+
+	if err := newProbe(t, config.HealthCheck{}).Check(context.Background(), backendURL); err == nil {
+		t.Error("Expected the backend port not to be alive")
+	}
+	if err := newProbe(t, config.HealthCheck{Port: port}).Check(context.Background(), backendURL); err != nil {
+		t.Errorf("Expected the management port to be alive, got error %v", err)
+	}
+}
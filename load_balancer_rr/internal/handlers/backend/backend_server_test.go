@@ -1,10 +1,13 @@
 package backend
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
-	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestNewBackendServer tests the initialization of a backendServer instance.
@@ -18,32 +21,24 @@ func TestNewBackendServer(t *testing.T) {
 		t.Errorf("Expected URL to be 'http://localhost:8080', got '%s'", bs.GetURL())
 	}
 
-	alive := bs.IsAlive()
-	if !alive.Load() {
+	if !bs.IsAlive() {
 		t.Error("Expected backendServer to be alive upon initialization")
 	}
 }
 
 // TestSetAlive tests the SetAlive method.
 func TestSetAlive(t *testing.T) {
-	aliveStatusFalse := atomic.Bool{}
-	aliveStatusTrue := atomic.Bool{}
-
-	aliveStatusFalse.Store(false)
-	aliveStatusTrue.Store(true)
 	parsedURL, _ := url.Parse("http://localhost:8080")
 	proxy := httputil.NewSingleHostReverseProxy(parsedURL)
 	bs := NewBackendServer(parsedURL, proxy)
 
-	bs.SetAlive(aliveStatusFalse)
-	alive := bs.IsAlive()
-	if alive.Load() {
+	bs.SetAlive(false)
+	if bs.IsAlive() {
 		t.Error("Expected backendServer to be not alive after setting to false")
 	}
 
-	bs.SetAlive(aliveStatusTrue)
-	isAlive := bs.IsAlive()
-	if !isAlive.Load() {
+	bs.SetAlive(true)
+	if !bs.IsAlive() {
 		t.Error("Expected backendServer to be alive after setting to true")
 	}
 }
@@ -58,3 +53,231 @@ func TestGetURL(t *testing.T) {
 		t.Error("GetURL did not return the expected URL")
 	}
 }
+
+// TestGetWeight tests the default and configured weight of a backendServer.
+func TestGetWeight(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	proxy := httputil.NewSingleHostReverseProxy(parsedURL)
+
+	if weight := NewBackendServer(parsedURL, proxy).GetWeight(); weight != 1 {
+		t.Errorf("Expected default weight to be 1, got %d", weight)
+	}
+
+	if weight := NewBackendServer(parsedURL, proxy, WithWeight(5)).GetWeight(); weight != 5 {
+		t.Errorf("Expected weight to be 5, got %d", weight)
+	}
+
+	if weight := NewBackendServer(parsedURL, proxy, WithWeight(0)).GetWeight(); weight != 1 {
+		t.Errorf("Expected non-positive weight to be ignored, got %d", weight)
+	}
+}
+
+// TestGetEffectiveWeight tests the slow start ramp of the effective weight.
+func TestGetEffectiveWeight(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	proxy := httputil.NewSingleHostReverseProxy(parsedURL)
+
+	// Without slow start the full weight applies immediately
+	if weight := NewBackendServer(parsedURL, proxy, WithWeight(4)).GetEffectiveWeight(); weight != 4 {
+		t.Errorf("Expected effective weight to be 4, got %v", weight)
+	}
+
+	bs := NewBackendServer(parsedURL, proxy, WithWeight(10), WithSlowStart(time.Minute)).(*backendServer)
+
+	// A new backendServer starts from the minimum share of its weight
+	if weight := bs.GetEffectiveWeight(); weight != 10*MinSlowStartFactor {
+		t.Errorf("Expected effective weight to start at %v, got %v", 10*MinSlowStartFactor, weight)
+	}
+
+	// Half way through the window it has half of its weight
+	bs.aliveSince.Store(time.Now().Add(-30 * time.Second).UnixNano())
+	if weight := bs.GetEffectiveWeight(); weight < 4.9 || weight > 5.1 {
+		t.Errorf("Expected effective weight to be about 5, got %v", weight)
+	}
+
+	// After the window it has its full weight
+	bs.aliveSince.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if weight := bs.GetEffectiveWeight(); weight != 10 {
+		t.Errorf("Expected effective weight to be 10, got %v", weight)
+	}
+
+	// Recovering from dead restarts the ramp, staying alive does not
+	bs.SetAlive(true)
+	if weight := bs.GetEffectiveWeight(); weight != 10 {
+		t.Errorf("Expected effective weight to stay at 10, got %v", weight)
+	}
+	bs.SetAlive(false)
+	bs.SetAlive(true)
+	if weight := bs.GetEffectiveWeight(); weight != 10*MinSlowStartFactor {
+		t.Errorf("Expected effective weight to restart at %v, got %v", 10*MinSlowStartFactor, weight)
+	}
+}
+
+// TestGetPriority tests the default and configured priority of a backendServer.
+func TestGetPriority(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	proxy := httputil.NewSingleHostReverseProxy(parsedURL)
+
+	if priority := NewBackendServer(parsedURL, proxy).GetPriority(); priority != 0 {
+		t.Errorf("Expected default priority to be 0, got %d", priority)
+	}
+
+	if priority := NewBackendServer(parsedURL, proxy, WithPriority(2)).GetPriority(); priority != 2 {
+		t.Errorf("Expected priority to be 2, got %d", priority)
+	}
+}
+
+// TestGetZone tests the configured zone of a backendServer.
+func TestGetZone(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	proxy := httputil.NewSingleHostReverseProxy(parsedURL)
+
+	if zone := NewBackendServer(parsedURL, proxy).GetZone(); zone != "" {
+		t.Errorf("Expected default zone to be empty, got %s", zone)
+	}
+
+	if zone := NewBackendServer(parsedURL, proxy, WithZone("ap-southeast-1a")).GetZone(); zone != "ap-southeast-1a" {
+		t.Errorf("Expected zone to be ap-southeast-1a, got %s", zone)
+	}
+}
+
+// TestGetActiveConnections tests that in-flight requests are tracked while being proxied.
+func TestGetActiveConnections(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{})
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	parsedURL, _ := url.Parse(mockServer.URL)
+	bs := NewBackendServer(parsedURL, httputil.NewSingleHostReverseProxy(parsedURL))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bs.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/create", nil))
+	}()
+
+	<-received
+	if active := bs.GetActiveConnections(); active != 1 {
+		t.Errorf("Expected 1 active connection while proxying, got %d", active)
+	}
+
+	close(release)
+	<-done
+	if active := bs.GetActiveConnections(); active != 0 {
+		t.Errorf("Expected 0 active connections after the response, got %d", active)
+	}
+}
+
+// TestRecordHealthCheck tests the consecutive health check history of a backendServer.
+func TestRecordHealthCheck(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	bs := NewBackendServer(parsedURL, httputil.NewSingleHostReverseProxy(parsedURL))
+
+	steps := []struct {
+		healthy   bool
+		successes int
+		failures  int
+	}{
+		{healthy: true, successes: 1, failures: 0},
+		{healthy: true, successes: 2, failures: 0},
+		{healthy: false, successes: 0, failures: 1},
+		{healthy: false, successes: 0, failures: 2},
+		{healthy: false, successes: 0, failures: 3},
+		{healthy: true, successes: 1, failures: 0},
+	}
+	if checked, _ := bs.GetLastHealthCheck(); !checked.IsZero() {
+		t.Errorf("Expected no last health check, got %v", checked)
+	}
+
+	for i, step := range steps {
+		var checkErr error
+		if !step.healthy {
+			checkErr = errors.New("unexpected health check status 500")
+		}
+		successes, failures := bs.RecordHealthCheck(checkErr)
+		if successes != step.successes || failures != step.failures {
+			t.Errorf("step %d: expected %d successes and %d failures, got %d and %d",
+				i, step.successes, step.failures, successes, failures)
+		}
+		if successes, failures = bs.GetHealthHistory(); successes != step.successes || failures != step.failures {
+			t.Errorf("step %d: expected history of %d successes and %d failures, got %d and %d",
+				i, step.successes, step.failures, successes, failures)
+		}
+		if checked, lastErr := bs.GetLastHealthCheck(); checked.IsZero() || lastErr != checkErr {
+			t.Errorf("step %d: expected last health check error %v, got %v at %v", i, checkErr, lastErr, checked)
+		}
+	}
+}
+
+// TestEject tests that an ejected backendServer is alive but not available until the ejection ends.
+func TestEject(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	bs := NewBackendServer(parsedURL, httputil.NewSingleHostReverseProxy(parsedURL))
+
+	if bs.IsEjected() || !bs.IsAvailable() {
+		t.Fatal("Expected a new backendServer to be available")
+	}
+
+	bs.Eject(time.Now().Add(50 * time.Millisecond))
+	if !bs.IsEjected() || bs.IsAvailable() || !bs.IsAlive() {
+		t.Error("Expected an ejected backendServer to be alive but not available")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if bs.IsEjected() || !bs.IsAvailable() {
+		t.Error("Expected the backendServer to be available once the ejection ended")
+	}
+
+	bs.SetAlive(false)
+	if bs.IsAvailable() {
+		t.Error("Expected a dead backendServer not to be available")
+	}
+}
+
+// TestWithPending tests that a pending backendServer stays out of rotation until it is first marked alive.
+func TestWithPending(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	bs := NewBackendServer(parsedURL, httputil.NewSingleHostReverseProxy(parsedURL), WithPending())
+
+	if !bs.IsPending() || bs.IsAlive() || bs.IsAvailable() {
+		t.Fatal("Expected a pending backendServer to be dead and not available")
+	}
+
+	bs.SetAlive(true)
+	if bs.IsPending() || !bs.IsAvailable() {
+		t.Error("Expected the backendServer to be available and no longer pending once alive")
+	}
+
+	if NewBackendServer(parsedURL, nil).IsPending() {
+		t.Error("Expected a backendServer not to be pending by default")
+	}
+}
+
+// TestDrain tests that a draining backendServer takes no new requests while it stays alive.
+func TestDrain(t *testing.T) {
+	parsedURL, _ := url.Parse("http://localhost:8080")
+	bs := NewBackendServer(parsedURL, nil)
+
+	if bs.IsDraining() || !bs.Drain() {
+		t.Fatal("Expected the backendServer to start draining")
+	}
+	if bs.Drain() {
+		t.Error("Expected draining an already draining backendServer to report false")
+	}
+
+	if !bs.IsDraining() || !bs.IsAlive() || bs.IsAvailable() {
+		t.Error("Expected a draining backendServer to stay alive but not be available")
+	}
+
+	if !bs.Undrain() || bs.IsDraining() || !bs.IsAvailable() {
+		t.Error("Expected an undrained backendServer to be available again")
+	}
+	if bs.Undrain() {
+		t.Error("Expected undraining a backendServer not draining to report false")
+	}
+}
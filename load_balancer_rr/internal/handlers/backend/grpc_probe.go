@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+// grpcProbe checks if the server is alive with the standard grpc.health.v1.Health/Check call,
+// the server is alive when it answers SERVING.
+type grpcProbe struct {
+	timeout time.Duration
+	service string
+	port    int
+}
+
+func newGRPCProbe(endpoint config.Endpoint, healthCheck config.HealthCheck) *grpcProbe {
+	return &grpcProbe{
+		timeout: time.Duration(endpoint.Timeout) * time.Second,
+		service: healthCheck.GRPCService,
+		port:    healthCheck.Port,
+	}
+}
+
+// Check calls the health service of the server over a new connection, using TLS for https backends.
+func (p *grpcProbe) Check(ctx context.Context, u *url.URL) error {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	creds := insecure.NewCredentials()
+	if u.Scheme == "https" {
+		creds = credentials.NewTLS(&tls.Config{ServerName: u.Hostname()})
+	}
+	conn, err := grpc.NewClient(probeAddress(u, p.port), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.service})
+	if err != nil {
+		//push a metrics
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("unexpected gRPC health check status %s", resp.GetStatus())
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+// newGRPCHealthServer starts a local gRPC server exposing the standard health service.
+func newGRPCHealthServer(t *testing.T) (*health.Server, *url.URL) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	serverURL, _ := url.Parse("http://" + listener.Addr().String())
+	return healthServer, serverURL
+}
+
+func newGRPCProbeForService(t *testing.T, service string) Probe {
+	probe, err := NewProbe(config.Backend{
+		Endpoint:    map[string]config.Endpoint{"healthcheck": {Timeout: 2}},
+		HealthCheck: config.HealthCheck{Type: "grpc", GRPCService: service},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create probe: %v", err)
+	}
+	return probe
+}
+
+func TestGRPCProbe(t *testing.T) {
+	healthServer, serverURL := newGRPCHealthServer(t)
+	healthServer.SetServingStatus("game.Game", healthpb.HealthCheckResponse_SERVING)
+
+	// The whole server is serving by default
+	if err := newGRPCProbeForService(t, "").Check(context.Background(), serverURL); err != nil {
+		t.Errorf("Expected the server to be alive, got error %v", err)
+	}
+	if err := newGRPCProbeForService(t, "game.Game").Check(context.Background(), serverURL); err != nil {
+		t.Errorf("Expected the service to be alive, got error %v", err)
+	}
+
+	healthServer.SetServingStatus("game.Game", healthpb.HealthCheckResponse_NOT_SERVING)
+	if err := newGRPCProbeForService(t, "game.Game").Check(context.Background(), serverURL); err == nil {
+		t.Error("Expected the not serving service not to be alive")
+	}
+
+	// The health service answers NOT_FOUND for an unknown service
+	if err := newGRPCProbeForService(t, "game.Unknown").Check(context.Background(), serverURL); err == nil {
+		t.Error("Expected an unknown service not to be alive")
+	}
+}
+
+func TestGRPCProbe_Unreachable(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	serverURL, _ := url.Parse("http://" + listener.Addr().String())
+	listener.Close()
+
+	if err := newGRPCProbeForService(t, "").Check(context.Background(), serverURL); err == nil {
+		t.Error("Expected the unreachable server not to be alive")
+	}
+}
@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// healthHistory counts the consecutive successful and failed health checks of a backend,
+// a result of the other kind resets the count, along with the time and error of the last check.
+type healthHistory struct {
+	mux         sync.Mutex
+	successes   int
+	failures    int
+	lastChecked time.Time
+	lastErr     error
+}
+
+// record adds the result of a health check, nil when it succeeded, and returns the consecutive counts.
+func (hh *healthHistory) record(err error, now time.Time) (successes int, failures int) {
+	hh.mux.Lock()
+	defer hh.mux.Unlock()
+
+	if err == nil {
+		hh.successes++
+		hh.failures = 0
+	} else {
+		hh.failures++
+		hh.successes = 0
+	}
+	hh.lastChecked, hh.lastErr = now, err
+	return hh.successes, hh.failures
+}
+
+// counts returns the consecutive successful and failed health checks.
+func (hh *healthHistory) counts() (successes int, failures int) {
+	hh.mux.Lock()
+	defer hh.mux.Unlock()
+	return hh.successes, hh.failures
+}
+
+// last returns the time and error of the last health check, the zero time when there was none.
+func (hh *healthHistory) last() (time.Time, error) {
+	hh.mux.Lock()
+	defer hh.mux.Unlock()
+	return hh.lastChecked, hh.lastErr
+}
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// latencyDecayWindow is the time constant of the latency moving average,
+// observations older than this weigh less than a third of the average.
+const latencyDecayWindow = 10 * time.Second
+
+// latencyTracker keeps a peak exponentially weighted moving average of response times
+// along with the start times of the requests still in flight.
+type latencyTracker struct {
+	mux          sync.Mutex
+	ewma         time.Duration
+	lastObserved time.Time
+	// pendingStartSum and pending describe the in-flight requests, their mean start time
+	// tells how long requests have been waiting on the backend
+	pendingStartSum int64
+	pending         int64
+}
+
+// start records the start of a proxied request.
+func (lt *latencyTracker) start(now time.Time) {
+	lt.mux.Lock()
+	defer lt.mux.Unlock()
+
+	lt.pendingStartSum += now.UnixNano()
+	lt.pending++
+}
+
+// finish records the completion of a proxied request started at the given time.
+func (lt *latencyTracker) finish(started, now time.Time) {
+	lt.mux.Lock()
+	defer lt.mux.Unlock()
+
+	lt.pendingStartSum -= started.UnixNano()
+	lt.pending--
+
+	rtt := now.Sub(started)
+	switch {
+	case lt.lastObserved.IsZero(), rtt > lt.ewma:
+		// Peak sensitivity: a slower response immediately raises the average
+		lt.ewma = rtt
+	default:
+		// Decay towards the new observation based on the time since the previous one
+		weight := math.Exp(-float64(now.Sub(lt.lastObserved)) / float64(latencyDecayWindow))
+		lt.ewma = time.Duration(float64(lt.ewma)*weight + float64(rtt)*(1-weight))
+	}
+	lt.lastObserved = now
+}
+
+// score returns the moving average, raised to the mean age of the in-flight requests when
+// they have been waiting longer, so a stalled backend is penalised before its requests complete.
+func (lt *latencyTracker) score(now time.Time) time.Duration {
+	lt.mux.Lock()
+	defer lt.mux.Unlock()
+
+	if lt.pending > 0 {
+		meanAge := time.Duration(now.UnixNano() - lt.pendingStartSum/lt.pending)
+		if meanAge > lt.ewma {
+			return meanAge
+		}
+	}
+	return lt.ewma
+}
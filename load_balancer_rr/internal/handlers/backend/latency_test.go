@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	var lt latencyTracker
+
+	if score := lt.score(base); score != 0 {
+		t.Errorf("Expected an unobserved backend to score 0, got %v", score)
+	}
+
+	// The first observation seeds the average
+	lt.start(base)
+	lt.finish(base, base.Add(100*time.Millisecond))
+	if score := lt.score(base); score != 100*time.Millisecond {
+		t.Errorf("Expected score 100ms after the first response, got %v", score)
+	}
+
+	// A slower response raises the average immediately
+	lt.start(base)
+	lt.finish(base, base.Add(300*time.Millisecond))
+	if score := lt.score(base); score != 300*time.Millisecond {
+		t.Errorf("Expected peak score 300ms, got %v", score)
+	}
+
+	// A faster response decays the average based on the elapsed time
+	started := base.Add(latencyDecayWindow)
+	lt.start(started)
+	lt.finish(started, started.Add(100*time.Millisecond))
+	score := lt.score(started)
+	if score <= 100*time.Millisecond || score >= 300*time.Millisecond {
+		t.Errorf("Expected decayed score between 100ms and 300ms, got %v", score)
+	}
+
+	// A stalled in-flight request dominates the score while pending
+	stalledAt := started.Add(time.Second)
+	lt.start(stalledAt)
+	if score := lt.score(stalledAt.Add(50 * time.Second)); score != 50*time.Second {
+		t.Errorf("Expected stalled request to raise the score to 50s, got %v", score)
+	}
+}
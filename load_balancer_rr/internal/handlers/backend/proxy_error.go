@@ -0,0 +1,30 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+// ProxyErrorHandler answers the requests the reverse proxy failed to forward, with a 504 Gateway Timeout
+// when the backend timed out and a 502 Bad Gateway otherwise.
+func ProxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusBadGateway
+	if IsTimeout(err) {
+		status = http.StatusGatewayTimeout
+	}
+
+	config.Logger.Warn("proxy error", zap.String("host", r.URL.Host), zap.Int("status", status), zap.Error(err))
+	w.WriteHeader(status)
+}
+
+// IsTimeout reports whether the proxy error is a timeout of the backend.
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// timeoutError is a net.Error timing out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// TestProxyErrorHandler tests the status answered for the proxy errors.
+func TestProxyErrorHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "connection refused", err: errors.New("dial tcp: connection refused"), status: http.StatusBadGateway},
+		{name: "deadline exceeded", err: fmt.Errorf("proxy: %w", context.DeadlineExceeded), status: http.StatusGatewayTimeout},
+		{name: "net timeout", err: fmt.Errorf("proxy: %w", timeoutError{}), status: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ProxyErrorHandler(rr, httptest.NewRequest(http.MethodGet, "/create", nil), tt.err)
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+		})
+	}
+}
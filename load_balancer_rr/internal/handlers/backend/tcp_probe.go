@@ -0,0 +1,33 @@
+package backend
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+// tcpProbe checks if the server is alive by opening a TCP connection to it, for upstreams that do not speak HTTP.
+type tcpProbe struct {
+	dialer *net.Dialer
+	port   int
+}
+
+func newTCPProbe(endpoint config.Endpoint, healthCheck config.HealthCheck) *tcpProbe {
+	return &tcpProbe{
+		dialer: &net.Dialer{Timeout: time.Duration(endpoint.Timeout) * time.Second},
+		port:   healthCheck.Port,
+	}
+}
+
+// Check connects to the server and closes the connection right away.
+func (p *tcpProbe) Check(ctx context.Context, u *url.URL) error {
+	conn, err := p.dialer.DialContext(ctx, "tcp", probeAddress(u, p.port))
+	if err != nil {
+		//push a metrics
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+func TestTCPProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	serverURL, _ := url.Parse("tcp://" + listener.Addr().String())
+	probe, err := NewProbe(config.Backend{HealthCheck: config.HealthCheck{Type: "tcp"}})
+	if err != nil {
+		t.Fatalf("Failed to create probe: %v", err)
+	}
+
+	if err = probe.Check(context.Background(), serverURL); err != nil {
+		t.Errorf("Expected the listening server to be alive, got error %v", err)
+	}
+
+	listener.Close()
+	if err = probe.Check(context.Background(), serverURL); err == nil {
+		t.Error("Expected the closed server not to be alive")
+	}
+}
+
+func TestNewProbe_UnknownType(t *testing.T) {
+	if _, err := NewProbe(config.Backend{HealthCheck: config.HealthCheck{Type: "udp"}}); err == nil {
+		t.Error("Expected an error for an unknown health check type")
+	}
+}
+
+func TestProbeAddress(t *testing.T) {
+	tests := []struct {
+		url      string
+		port     int
+		expected string
+	}{
+		{url: "http://localhost:8080", expected: "localhost:8080"},
+		{url: "http://localhost:8080", port: 9090, expected: "localhost:9090"},
+		{url: "http://game.internal", expected: "game.internal:80"},
+		{url: "https://game.internal", expected: "game.internal:443"},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if address := probeAddress(u, tt.port); address != tt.expected {
+			t.Errorf("Expected address of %s with port %d to be %s, got %s", tt.url, tt.port, tt.expected, address)
+		}
+	}
+}
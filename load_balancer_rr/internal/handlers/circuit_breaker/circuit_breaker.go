@@ -0,0 +1,274 @@
+package circuit_breaker
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/pkg/utils"
+)
+
+const (
+	// DefaultWindow is the length of the failure rate window.
+	DefaultWindow = 10 * time.Second
+	// DefaultMinRequests is the number of requests a window needs for its failure rate to count.
+	DefaultMinRequests = 20
+	// DefaultOpenTimeout is the time the breaker stays open before letting trial requests through.
+	DefaultOpenTimeout = 30 * time.Second
+	// DefaultHalfOpenRequests is the number of trial requests that must succeed to close the breaker.
+	DefaultHalfOpenRequests = 3
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets every request through while counting the failures.
+	Closed State = iota
+	// Open rejects every request until the open timeout is over.
+	Open
+	// HalfOpen lets a limited number of trial requests through to probe the backend.
+	HalfOpen
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker wraps a backend.Backend and stops sending it requests once it fails too often.
+// The breaker opens on a high failure rate over a window or on consecutive timeouts, and the
+// backend is unavailable while it is open. Once the open timeout is over, a limited number of trial
+// requests go through: the breaker closes when they all succeed and opens again on the first failure.
+type CircuitBreaker struct {
+	backend.Backend
+	service             string
+	failureRate         float64
+	window              time.Duration
+	minRequests         int
+	consecutiveTimeouts int
+	openTimeout         time.Duration
+	halfOpenRequests    int
+
+	mux   sync.Mutex
+	state State
+	// openedAt is the time the breaker last opened
+	openedAt time.Time
+	// windowStart, requests and failures describe the current failure rate window
+	windowStart time.Time
+	requests    int
+	failures    int
+	timeouts    int
+	// trials and trialSuccesses count the trial requests let through while half open
+	trials         int
+	trialSuccesses int
+	now            func() time.Time
+}
+
+// Wrap returns the backend wrapped in a circuit breaker, or the backend itself when the breaker is disabled.
+func Wrap(service string, circuitBreaker config.CircuitBreaker, b backend.Backend) backend.Backend {
+	if circuitBreaker.FailureRate <= 0 && circuitBreaker.ConsecutiveTimeouts <= 0 {
+		return b
+	}
+	return New(service, circuitBreaker, b)
+}
+
+// New initializes and returns a new closed CircuitBreaker around the backend of the service.
+func New(service string, circuitBreaker config.CircuitBreaker, b backend.Backend) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		Backend:             b,
+		service:             service,
+		failureRate:         circuitBreaker.FailureRate,
+		window:              utils.SecondsOrDefault(circuitBreaker.Window, DefaultWindow),
+		minRequests:         circuitBreaker.MinRequests,
+		consecutiveTimeouts: circuitBreaker.ConsecutiveTimeouts,
+		openTimeout:         utils.SecondsOrDefault(circuitBreaker.OpenTimeout, DefaultOpenTimeout),
+		halfOpenRequests:    circuitBreaker.HalfOpenRequests,
+		now:                 time.Now,
+	}
+	if cb.minRequests <= 0 {
+		cb.minRequests = DefaultMinRequests
+	}
+	if cb.halfOpenRequests <= 0 {
+		cb.halfOpenRequests = DefaultHalfOpenRequests
+	}
+	cb.windowStart = cb.now()
+	return cb
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker) State() State {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+	return cb.currentState(cb.now())
+}
+
+// IsAvailable checks if the backend can take new requests: it is available and its breaker is closed,
+// or half open with trial requests left.
+func (cb *CircuitBreaker) IsAvailable() bool {
+	if !cb.Backend.IsAvailable() {
+		return false
+	}
+
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+	switch cb.currentState(cb.now()) {
+	case Open:
+		return false
+	case HalfOpen:
+		return cb.trials < cb.halfOpenRequests
+	default:
+		return true
+	}
+}
+
+// Serve forwards the request to the backend when the breaker lets it through and records its outcome,
+// it answers with a 503 Service Unavailable when the breaker rejects it.
+func (cb *CircuitBreaker) Serve(rw http.ResponseWriter, req *http.Request) {
+	if !cb.TryServe(rw, req) {
+		http.Error(rw, "Service not available", http.StatusServiceUnavailable)
+	}
+}
+
+// TryServe forwards the request to the backend when the breaker lets it through and records its outcome.
+// It reports false without writing the response when the breaker rejects it, e.g. when the trial requests
+// of a half open breaker were taken since it was selected, so the request can go to another backend.
+func (cb *CircuitBreaker) TryServe(rw http.ResponseWriter, req *http.Request) bool {
+	if !cb.acquire() {
+		return false
+	}
+
+	recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+	cb.Backend.Serve(recorder, req)
+	if req.Context().Err() != nil {
+		// The client went away, which tells nothing about the backend
+		cb.release()
+		return true
+	}
+	cb.record(recorder.status)
+	return true
+}
+
+// release gives back the trial slot of a request whose outcome is unknown.
+func (cb *CircuitBreaker) release() {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+	if cb.state == HalfOpen && cb.trials > 0 {
+		cb.trials--
+	}
+}
+
+// acquire reports whether the breaker lets a request through, counting it as a trial when half open.
+func (cb *CircuitBreaker) acquire() bool {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+
+	switch cb.currentState(cb.now()) {
+	case Open:
+		return false
+	case HalfOpen:
+		if cb.trials >= cb.halfOpenRequests {
+			return false
+		}
+		cb.trials++
+	}
+	return true
+}
+
+// record adds the outcome of a request, 5xx responses count as failures and 504 responses as timeouts.
+func (cb *CircuitBreaker) record(status int) {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+
+	now := cb.now()
+	failed := status >= http.StatusInternalServerError
+	timedOut := status == http.StatusGatewayTimeout
+
+	switch cb.currentState(now) {
+	case HalfOpen:
+		if failed {
+			cb.transition(Open, now)
+			return
+		}
+		cb.trialSuccesses++
+		if cb.trialSuccesses >= cb.halfOpenRequests {
+			cb.transition(Closed, now)
+		}
+	case Closed:
+		if now.Sub(cb.windowStart) >= cb.window {
+			cb.windowStart, cb.requests, cb.failures = now, 0, 0
+		}
+		cb.requests++
+		if failed {
+			cb.failures++
+		}
+		if timedOut {
+			cb.timeouts++
+		} else {
+			cb.timeouts = 0
+		}
+
+		tooManyTimeouts := cb.consecutiveTimeouts > 0 && cb.timeouts >= cb.consecutiveTimeouts
+		tooManyFailures := cb.failureRate > 0 && cb.requests >= cb.minRequests &&
+			float64(cb.failures)*100/float64(cb.requests) >= cb.failureRate
+		if tooManyTimeouts || tooManyFailures {
+			cb.transition(Open, now)
+		}
+	}
+}
+
+// currentState returns the state of the breaker, moving an open breaker to half open once its open timeout is over.
+// It must be called with the lock held.
+func (cb *CircuitBreaker) currentState(now time.Time) State {
+	if cb.state == Open && now.Sub(cb.openedAt) >= cb.openTimeout {
+		cb.transition(HalfOpen, now)
+	}
+	return cb.state
+}
+
+// transition moves the breaker to the state, resetting its counters. It must be called with the lock held.
+func (cb *CircuitBreaker) transition(state State, now time.Time) {
+	from := cb.state
+	cb.state = state
+	cb.windowStart, cb.requests, cb.failures, cb.timeouts = now, 0, 0, 0
+	cb.trials, cb.trialSuccesses = 0, 0
+	if state == Open {
+		cb.openedAt = now
+	}
+
+	// Push alert here: circuit breaker state changed
+	config.Logger.Info("circuit breaker state changed", zap.String("service", cb.service),
+		zap.String("host", cb.GetURL().String()), zap.String("from", from.String()), zap.String("to", state.String()))
+}
+
+// statusRecorder records the status of the response written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	// Informational responses precede the final one
+	if !sr.wroteHeader && status >= http.StatusOK {
+		sr.status = status
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, so the reverse proxy can still flush streamed responses.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
@@ -0,0 +1,207 @@
+package circuit_breaker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// MockBackend is a mock implementation of the backend.Backend interface answering with a fixed status.
+type MockBackend struct {
+	// Backend satisfies the methods the circuit breaker does not rely on
+	backend.Backend
+	url       *url.URL
+	status    int
+	available bool
+	served    int
+}
+
+func (m *MockBackend) GetURL() *url.URL {
+	return m.url
+}
+
+func (m *MockBackend) IsAvailable() bool {
+	return m.available
+}
+
+func (m *MockBackend) Serve(w http.ResponseWriter, r *http.Request) {
+	m.served++
+	w.WriteHeader(m.status)
+}
+
+// newBreaker returns a circuit breaker around a mock backend, driven by the returned clock.
+func newBreaker(circuitBreaker config.CircuitBreaker) (*CircuitBreaker, *MockBackend, *time.Time) {
+	clock := time.Unix(1700000000, 0)
+	u, _ := url.Parse("http://localhost:8080")
+	mockBackend := &MockBackend{url: u, status: http.StatusOK, available: true}
+
+	cb := New("game", circuitBreaker, mockBackend)
+	cb.now = func() time.Time { return clock }
+	cb.windowStart = clock
+	return cb, mockBackend, &clock
+}
+
+func serve(cb *CircuitBreaker, times int) *httptest.ResponseRecorder {
+	var rr *httptest.ResponseRecorder
+	for i := 0; i < times; i++ {
+		rr = httptest.NewRecorder()
+		cb.Serve(rr, httptest.NewRequest(http.MethodGet, "/create", nil))
+	}
+	return rr
+}
+
+func TestWrap(t *testing.T) {
+	u, _ := url.Parse("http://localhost:8080")
+	mockBackend := &MockBackend{url: u}
+
+	assert.Equal(t, mockBackend, Wrap("game", config.CircuitBreaker{}, mockBackend))
+
+	wrapped, ok := Wrap("game", config.CircuitBreaker{FailureRate: 50}, mockBackend).(*CircuitBreaker)
+	assert.True(t, ok)
+	assert.Equal(t, Closed, wrapped.State())
+	assert.Equal(t, DefaultWindow, wrapped.window)
+	assert.Equal(t, DefaultMinRequests, wrapped.minRequests)
+	assert.Equal(t, DefaultOpenTimeout, wrapped.openTimeout)
+	assert.Equal(t, DefaultHalfOpenRequests, wrapped.halfOpenRequests)
+}
+
+func TestCircuitBreaker_FailureRate(t *testing.T) {
+	cb, mockBackend, _ := newBreaker(config.CircuitBreaker{FailureRate: 50, MinRequests: 10})
+
+	serve(cb, 5)
+	mockBackend.status = http.StatusInternalServerError
+	serve(cb, 4)
+	assert.Equal(t, Closed, cb.State())
+
+	serve(cb, 1)
+	assert.Equal(t, Open, cb.State())
+	assert.False(t, cb.IsAvailable())
+
+	// An open breaker rejects the requests without reaching the backend
+	rr := serve(cb, 1)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, 10, mockBackend.served)
+}
+
+func TestCircuitBreaker_FailureRateWindow(t *testing.T) {
+	cb, mockBackend, clock := newBreaker(config.CircuitBreaker{FailureRate: 50, MinRequests: 10})
+
+	mockBackend.status = http.StatusInternalServerError
+	serve(cb, 9)
+	*clock = clock.Add(DefaultWindow)
+
+	// The failures of the previous window are forgotten
+	mockBackend.status = http.StatusOK
+	serve(cb, 9)
+	mockBackend.status = http.StatusInternalServerError
+	serve(cb, 1)
+	assert.Equal(t, Closed, cb.State())
+}
+
+func TestCircuitBreaker_ConsecutiveTimeouts(t *testing.T) {
+	cb, mockBackend, _ := newBreaker(config.CircuitBreaker{ConsecutiveTimeouts: 3})
+
+	mockBackend.status = http.StatusGatewayTimeout
+	serve(cb, 2)
+	mockBackend.status = http.StatusOK
+	serve(cb, 1)
+	mockBackend.status = http.StatusGatewayTimeout
+	serve(cb, 2)
+	assert.Equal(t, Closed, cb.State())
+
+	serve(cb, 1)
+	assert.Equal(t, Open, cb.State())
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	cb, mockBackend, clock := newBreaker(config.CircuitBreaker{ConsecutiveTimeouts: 1, OpenTimeout: 10, HalfOpenRequests: 2})
+
+	mockBackend.status = http.StatusGatewayTimeout
+	serve(cb, 1)
+	assert.Equal(t, Open, cb.State())
+
+	*clock = clock.Add(10 * time.Second)
+	assert.Equal(t, HalfOpen, cb.State())
+	assert.True(t, cb.IsAvailable())
+
+	// A failed trial opens the breaker again
+	mockBackend.status = http.StatusBadGateway
+	serve(cb, 1)
+	assert.Equal(t, Open, cb.State())
+
+	*clock = clock.Add(10 * time.Second)
+	mockBackend.status = http.StatusOK
+	serve(cb, 1)
+	assert.Equal(t, HalfOpen, cb.State())
+	serve(cb, 1)
+	assert.Equal(t, Closed, cb.State())
+	assert.True(t, cb.IsAvailable())
+}
+
+func TestCircuitBreaker_HalfOpenTrials(t *testing.T) {
+	cb, mockBackend, clock := newBreaker(config.CircuitBreaker{ConsecutiveTimeouts: 1, OpenTimeout: 10, HalfOpenRequests: 2})
+
+	mockBackend.status = http.StatusGatewayTimeout
+	serve(cb, 1)
+	*clock = clock.Add(10 * time.Second)
+
+	// Only the trial requests are let through while half open
+	assert.True(t, cb.acquire())
+	assert.True(t, cb.acquire())
+	assert.False(t, cb.acquire())
+	assert.False(t, cb.IsAvailable())
+
+	// A trial whose client went away gives its slot back
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cb.release()
+	assert.True(t, cb.IsAvailable())
+	cb.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/create", nil).WithContext(ctx))
+	assert.Equal(t, HalfOpen, cb.State())
+	assert.True(t, cb.IsAvailable())
+}
+
+func TestCircuitBreaker_TryServe(t *testing.T) {
+	cb, mockBackend, clock := newBreaker(config.CircuitBreaker{ConsecutiveTimeouts: 1, OpenTimeout: 10, HalfOpenRequests: 1})
+
+	rr := httptest.NewRecorder()
+	assert.True(t, cb.TryServe(rr, httptest.NewRequest(http.MethodGet, "/create", nil)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	mockBackend.status = http.StatusGatewayTimeout
+	serve(cb, 1)
+	*clock = clock.Add(10 * time.Second)
+	assert.True(t, cb.acquire())
+
+	// Once the trial is taken the request is rejected without an answer, so it can be served elsewhere
+	rr = httptest.NewRecorder()
+	assert.False(t, cb.TryServe(rr, httptest.NewRequest(http.MethodGet, "/create", nil)))
+	assert.False(t, rr.Flushed)
+	assert.Zero(t, rr.Body.Len())
+	assert.Equal(t, 2, mockBackend.served)
+
+	// Serve answers the rejected request itself
+	assert.Equal(t, http.StatusServiceUnavailable, serve(cb, 1).Code)
+}
+
+func TestCircuitBreaker_IsAvailable(t *testing.T) {
+	cb, mockBackend, _ := newBreaker(config.CircuitBreaker{FailureRate: 50})
+
+	assert.True(t, cb.IsAvailable())
+	mockBackend.available = false
+	assert.False(t, cb.IsAvailable())
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", Closed.String())
+	assert.Equal(t, "open", Open.String())
+	assert.Equal(t, "half_open", HalfOpen.String())
+}
@@ -2,12 +2,13 @@ package healthcheck
 
 import (
 	"context"
-	"sync/atomic"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/constant"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
 )
@@ -17,53 +18,139 @@ const (
 	UnhealthyStatus = "Unhealthy"
 )
 
-// PerformHealthCheck initiates a periodic health check for backend hosts in the server pool.
-func PerformHealthCheck(ctx context.Context, sp serverpool.ServerPool) {
+const (
+	// MaxConcurrentProbes bounds the backends of a service probed at the same time.
+	MaxConcurrentProbes = 16
+	// DefaultProbeTimeout bounds a probe when the health check endpoint sets no timeout.
+	DefaultProbeTimeout = 10 * time.Second
+	// DefaultRise is the number of consecutive successful checks bringing a dead backend back.
+	DefaultRise = 1
+	// DefaultFall is the number of consecutive failed checks marking an alive backend dead.
+	DefaultFall = 1
+)
+
+// PerformHealthCheck initiates a periodic health check for backend hosts in the server pool
+// of a service, using the health check endpoint configured for the service. Each backend is checked
+// on its own jittered schedule, more often while it is dead. The backends due at the same time are
+// checked in one round. Rounds run concurrently, so a hanging probe never delays the checks of the
+// backends falling due meanwhile, but a backend is never probed by two rounds at once. The rounds share
+// a semaphore, so at most MaxConcurrentProbes backends of the service are probed at a time.
+func PerformHealthCheck(ctx context.Context, sp serverpool.ServerPool, probe backend.Probe, backendConfig config.Backend) {
 	config.Logger.Info("Starting health check for backend hosts")
-	// Create a ticker to perform health checks at specified intervals.
-	ticker := time.NewTicker(time.Duration(config.Config.HealthCheckTickerTimeInSeconds) * time.Second)
+	checks := newSchedule(backendConfig.HealthCheck)
+	semaphore := make(chan struct{}, MaxConcurrentProbes)
+	finished := make(chan []backend.Backend)
+	var rounds sync.WaitGroup
+	defer rounds.Wait()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
 		select {
-		// Trigger health check on each tick.
-		case <-ticker.C:
-			go HealthCheck(ctx, sp)
+		// Trigger health check of the backends due.
+		case <-timer.C:
+		// Reschedule the backends of a finished round.
+		case checked := <-finished:
+			now := time.Now()
+			for _, b := range checked {
+				checks.reschedule(b, now)
+			}
 		// Handle context cancellation to gracefully stop health check execution.
 		case <-ctx.Done():
 			config.Logger.Info("Closing health check execution..")
 			return
 		}
+
+		if due := checks.due(sp.ListServiceBackends(), time.Now()); len(due) > 0 {
+			rounds.Add(1)
+			go func() {
+				defer rounds.Done()
+				HealthCheck(ctx, due, probe, backendConfig, semaphore)
+				select {
+				case finished <- due:
+				case <-ctx.Done():
+				}
+			}()
+		}
+		timer.Reset(checks.wait(time.Now()))
 	}
 }
 
-// HealthCheck verifies the status of each service backend in the server pool.
-var HealthCheck = func(ctx context.Context, sp serverpool.ServerPool) {
-	aliveChannel := make(chan atomic.Bool, 1) // Channel to receive the alive status of each service.
-
-	for _, service := range sp.ListServiceBackends() {
-		// Create a new context with a timeout for the health check request.
-		requestCtx, stop := context.WithTimeout(ctx, 10*time.Second)
-		healthStatus := HealthyStatus
+// HealthCheck verifies the status of the service backends in a round. The backends are
+// probed in parallel, each probe holding a slot of the semaphore shared by the rounds of the service, so a
+// hanging backend only delays its own result. The results are applied together once every probe of the round
+// has finished, following the rise and fall thresholds of the service.
+var HealthCheck = func(ctx context.Context, backends []backend.Backend, probe backend.Probe, backendConfig config.Backend, semaphore chan struct{}) {
+	endpoint := backendConfig.Endpoint[constant.Healthcheck]
+	probeTimeout := DefaultProbeTimeout
+	if endpoint.Timeout > 0 {
+		probeTimeout = time.Duration(endpoint.Timeout) * time.Second
+	}
 
-		// Asynchronously check if the backend service is alive.
-		go backend.IsServerAlive(requestCtx, aliveChannel, service.GetURL())
+	results := make([]error, len(backends))
+	var wg sync.WaitGroup
 
+	for i, service := range backends {
 		select {
 		// Handle context cancellation, logging a shutdown message.
 		case <-ctx.Done():
+			wg.Wait()
 			config.Logger.Info("Gracefully shutting down health check")
 			return
-		// Wait for the alive status from the channel.
-		case alive := <-aliveChannel:
-			service.SetAlive(alive)
-			if !alive.Load() {
-				// Push an alert here for a health check failure or configure the number of hosts.
-				healthStatus = UnhealthyStatus
-			}
+		case semaphore <- struct{}{}:
 		}
-		// Call stop to cancel the context for the current service health check.
-		stop()
 
-		config.Logger.Info("host status: ", zap.String("status", healthStatus), zap.String("host", service.GetURL().String()))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			// Create a new context with a timeout for the health check request.
+			requestCtx, stop := context.WithTimeout(ctx, probeTimeout)
+			defer stop()
+			results[i] = probe.Check(requestCtx, service.GetURL())
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		config.Logger.Info("Gracefully shutting down health check")
+		return
+	}
+
+	for i, service := range backends {
+		applyResult(service, results[i], backendConfig.HealthCheck)
+	}
+}
+
+// applyResult records the result of a probe on the backend and changes its alive state once the
+// backend has failed fall consecutive checks, or succeeded rise consecutive checks when it is dead.
+// A backend pending its first check is brought in by a single success.
+func applyResult(service backend.Backend, err error, healthCheck config.HealthCheck) {
+	rise, fall := DefaultRise, DefaultFall
+	if healthCheck.Rise > 0 {
+		rise = healthCheck.Rise
+	}
+	if healthCheck.Fall > 0 {
+		fall = healthCheck.Fall
+	}
+
+	successes, failures := service.RecordHealthCheck(err)
+	alive := service.IsAlive()
+	switch {
+	case alive && failures >= fall:
+		service.SetAlive(false)
+	// A pending backend joins the pool on its first successful check
+	case !alive && (successes >= rise || service.IsPending() && err == nil):
+		service.SetAlive(true)
+	}
+
+	if !service.IsAlive() {
+		// Push an alert here for a health check failure or configure the number of hosts.
+		config.Logger.Info("host status: ", zap.String("status", UnhealthyStatus),
+			zap.String("host", service.GetURL().String()), zap.Int("successes", successes), zap.Error(err))
+		return
 	}
+	config.Logger.Info("host status: ", zap.String("status", HealthyStatus),
+		zap.String("host", service.GetURL().String()), zap.Int("failures", failures), zap.Error(err))
 }
@@ -2,16 +2,23 @@ package healthcheck
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
 	"go.uber.org/zap"
 
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/coda-payments/load_balancer_rr/internal/config"
-	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
 )
 
 // MockServerPool is a mock implementation of the ServerPool interface.
@@ -20,8 +27,8 @@ type MockServerPool struct {
 }
 
 func (m *MockServerPool) ListServiceBackends() []backend.Backend {
-	//TODO implement me
-	panic("implement me")
+	args := m.Called()
+	return args.Get(0).([]backend.Backend)
 }
 
 func (m *MockServerPool) RemoveBackend(backend backend.Backend) {
@@ -34,6 +41,11 @@ func (m *MockServerPool) GetServerPoolSize() int32 {
 	panic("implement me")
 }
 
+func (m *MockServerPool) CountAvailableBackends() int {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (m *MockServerPool) NextAvailableBackend() backend.Backend {
 	args := m.Called()
 	return args.Get(0).(backend.Backend)
@@ -44,14 +56,19 @@ func (m *MockServerPool) RegisterServiceBackend(backend backend.Backend) {
 }
 
 // MockHealthCheck is a mock function to simulate HealthCheck behavior.
-func MockHealthCheck(ctx context.Context, sp serverpool.ServerPool) {
+func MockHealthCheck(ctx context.Context, backends []backend.Backend, probe backend.Probe, backendConfig config.Backend, semaphore chan struct{}) {
 	// Simulate some health check behavior
 }
 
 // TestPerformHealthCheck tests the PerformHealthCheck function.
 func TestPerformHealthCheck(t *testing.T) {
 	// Set up mocks
+	u, _ := url.Parse("http://localhost:8080")
 	mockServerPool := new(MockServerPool)
+	mockServerPool.On("ListServiceBackends").Return([]backend.Backend{
+		backend.NewBackendServer(u, nil),
+		backend.NewBackendServer(u, nil),
+	})
 
 	// Replace global Logger with a no-op logger for testing
 	config.Logger, _ = zap.NewProduction()
@@ -64,10 +81,18 @@ func TestPerformHealthCheck(t *testing.T) {
 	// Override the HealthCheck function with a mock
 	originalHealthCheck := HealthCheck
 	defer func() { HealthCheck = originalHealthCheck }()
-	HealthCheck = MockHealthCheck
+	var checked atomic.Int32
+	HealthCheck = func(ctx context.Context, backends []backend.Backend, probe backend.Probe, backendConfig config.Backend, semaphore chan struct{}) {
+		checked.Add(int32(len(backends)))
+		MockHealthCheck(ctx, backends, probe, backendConfig, semaphore)
+	}
 
 	// Start PerformHealthCheck in a separate goroutine
-	go PerformHealthCheck(ctx, mockServerPool)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		PerformHealthCheck(ctx, mockServerPool, nil, config.Config.Backend)
+	}()
 
 	// Allow some time for the ticker to trigger
 	time.Sleep(3 * time.Second)
@@ -75,6 +100,222 @@ func TestPerformHealthCheck(t *testing.T) {
 	// Cancel the context to stop health checks
 	cancel()
 
-	// Allow some time for context cancellation to be processed
-	time.Sleep(1 * time.Second)
+	// Wait for the context cancellation to be processed
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PerformHealthCheck did not stop after the context was cancelled")
+	}
+
+	// Each backend is checked within its first interval, then about once per interval
+	assert.GreaterOrEqual(t, checked.Load(), int32(4))
+	assert.LessOrEqual(t, checked.Load(), int32(8))
+}
+
+// TestPerformHealthCheck_HangingBackend tests that a hanging probe does not delay the checks of the other backends.
+func TestPerformHealthCheck_HangingBackend(t *testing.T) {
+	var hangingProbes, healthyProbes atomic.Int32
+	pool := round_robin.Initialize()
+	pool.RegisterServiceBackend(newProbedBackend(t, http.StatusOK, 10*time.Second, &hangingProbes))
+	pool.RegisterServiceBackend(newProbedBackend(t, http.StatusOK, 0, &healthyProbes))
+
+	backendConfig := healthCheckConfig(5)
+	backendConfig.HealthCheck = config.HealthCheck{Interval: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		PerformHealthCheck(ctx, pool, newProbe(t, backendConfig), backendConfig)
+	}()
+
+	time.Sleep(3 * time.Second)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PerformHealthCheck did not stop after the context was cancelled")
+	}
+
+	// The healthy backend keeps its interval while the hanging one is probed a single time
+	assert.Equal(t, int32(1), hangingProbes.Load())
+	assert.GreaterOrEqual(t, healthyProbes.Load(), int32(2))
+}
+
+// TestPerformHealthCheck_MaxConcurrentProbes tests that the rounds of a service share the bound on the probes in flight.
+func TestPerformHealthCheck_MaxConcurrentProbes(t *testing.T) {
+	pool := round_robin.Initialize()
+	for i := 0; i < 2*MaxConcurrentProbes; i++ {
+		u, _ := url.Parse(fmt.Sprintf("http://localhost:%d", 9000+i))
+		pool.RegisterServiceBackend(backend.NewBackendServer(u, nil))
+	}
+
+	backendConfig := healthCheckConfig(10)
+	backendConfig.HealthCheck = config.HealthCheck{Interval: 1}
+	probe := &hangingProbe{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		PerformHealthCheck(ctx, pool, probe, backendConfig)
+	}()
+
+	// The first checks of the backends are spread over the first interval, in several rounds
+	time.Sleep(2 * time.Second)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PerformHealthCheck did not stop after the context was cancelled")
+	}
+
+	assert.Equal(t, int32(MaxConcurrentProbes), probe.maxInFlight.Load())
+}
+
+// hangingProbe hangs until its context is done, keeping track of the most probes in flight at once.
+type hangingProbe struct {
+	inFlight, maxInFlight atomic.Int32
+}
+
+func (p *hangingProbe) Check(ctx context.Context, _ *url.URL) error {
+	inFlight := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		current := p.maxInFlight.Load()
+		if inFlight <= current || p.maxInFlight.CompareAndSwap(current, inFlight) {
+			break
+		}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// newProbedBackend returns a backend whose health check endpoint answers with the status after the delay.
+func newProbedBackend(t *testing.T, status int, delay time.Duration, probes *atomic.Int32) backend.Backend {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	return backend.NewBackendServer(u, nil)
+}
+
+func healthCheckConfig(timeout int) config.Backend {
+	return config.Backend{Endpoint: map[string]config.Endpoint{
+		"healthcheck": {URL: "/healthcheck", Timeout: timeout},
+	}}
+}
+
+// newSemaphore returns the semaphore bounding the probes in flight, as PerformHealthCheck creates it.
+func newSemaphore() chan struct{} {
+	return make(chan struct{}, MaxConcurrentProbes)
+}
+
+func newProbe(t *testing.T, backendConfig config.Backend) backend.Probe {
+	probe, err := backend.NewProbe(backendConfig)
+	assert.NoError(t, err)
+	return probe
+}
+
+// TestHealthCheck tests that the backends are probed in parallel and a hanging one only delays its own result.
+func TestHealthCheck(t *testing.T) {
+	var probes atomic.Int32
+	hanging := newProbedBackend(t, http.StatusOK, 10*time.Second, &probes)
+	backends := []backend.Backend{hanging}
+	for i := 0; i < 2*MaxConcurrentProbes; i++ {
+		status := http.StatusOK
+		if i%2 == 1 {
+			status = http.StatusInternalServerError
+		}
+		backends = append(backends, newProbedBackend(t, status, 100*time.Millisecond, &probes))
+	}
+
+	started := time.Now()
+	HealthCheck(context.Background(), backends, newProbe(t, healthCheckConfig(1)), healthCheckConfig(1), newSemaphore())
+
+	// The round lasts one probe timeout rather than the sum of the probes
+	assert.Less(t, time.Since(started), 3*time.Second)
+	assert.Equal(t, int32(len(backends)), probes.Load())
+
+	assert.False(t, hanging.IsAlive())
+	for i, b := range backends[1:] {
+		assert.Equal(t, i%2 == 0, b.IsAlive())
+	}
+}
+
+// TestHealthCheck_Cancelled tests that a cancelled round leaves the backends untouched.
+func TestHealthCheck_Cancelled(t *testing.T) {
+	var probes atomic.Int32
+	b := newProbedBackend(t, http.StatusInternalServerError, 10*time.Second, &probes)
+	b.SetAlive(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	HealthCheck(ctx, []backend.Backend{b}, newProbe(t, healthCheckConfig(5)), healthCheckConfig(5), newSemaphore())
+
+	assert.True(t, b.IsAlive())
+}
+
+// TestHealthCheck_RiseFall tests that a backend changes state only after rise or fall consecutive checks.
+func TestHealthCheck_RiseFall(t *testing.T) {
+	var probes atomic.Int32
+	status := atomic.Int32{}
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	b := backend.NewBackendServer(u, nil)
+
+	backendConfig := healthCheckConfig(1)
+	backendConfig.HealthCheck = config.HealthCheck{Rise: 2, Fall: 3}
+
+	steps := []struct {
+		status int
+		alive  bool
+	}{
+		{status: http.StatusInternalServerError, alive: true},
+		{status: http.StatusInternalServerError, alive: true},
+		// A success in between resets the failures
+		{status: http.StatusOK, alive: true},
+		{status: http.StatusInternalServerError, alive: true},
+		{status: http.StatusInternalServerError, alive: true},
+		{status: http.StatusInternalServerError, alive: false},
+		{status: http.StatusOK, alive: false},
+		{status: http.StatusOK, alive: true},
+	}
+	for i, step := range steps {
+		status.Store(int32(step.status))
+		HealthCheck(context.Background(), []backend.Backend{b}, newProbe(t, backendConfig), backendConfig, newSemaphore())
+		assert.Equal(t, step.alive, b.IsAlive(), "step %d", i)
+	}
+	assert.Equal(t, int32(len(steps)), probes.Load())
+}
+
+// TestHealthCheck_Pending tests that a pending backend joins on its first successful check despite the rise threshold.
+func TestHealthCheck_Pending(t *testing.T) {
+	var probes atomic.Int32
+	failing := newProbedBackend(t, http.StatusInternalServerError, 0, &probes)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	pending := backend.NewBackendServer(u, nil, backend.WithPending())
+
+	backendConfig := healthCheckConfig(1)
+	backendConfig.HealthCheck = config.HealthCheck{Rise: 3}
+	HealthCheck(context.Background(), []backend.Backend{pending, failing}, newProbe(t, backendConfig), backendConfig, newSemaphore())
+
+	assert.True(t, pending.IsAlive())
+	assert.False(t, pending.IsPending())
 }
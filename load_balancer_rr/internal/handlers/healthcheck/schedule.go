@@ -0,0 +1,110 @@
+package healthcheck
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+const (
+	// DefaultInterval is the time between the checks of a backend when no interval is configured at all.
+	DefaultInterval = 5 * time.Second
+	// DefaultJitterPercent is the share of the interval randomly added to or removed from each wait.
+	DefaultJitterPercent = 10
+)
+
+// schedule keeps the time of the next check of each backend of a service. Alive backends are checked
+// every interval and dead ones every unhealthy interval, each wait being jittered so the probes of
+// the backends spread over time instead of landing at the same moment. A backend being checked is
+// busy until it is rescheduled, so it is never checked twice at the same time.
+type schedule struct {
+	interval          time.Duration
+	unhealthyInterval time.Duration
+	// jitter is the fraction of the interval randomly added to or removed from each wait
+	jitter float64
+	next   map[backend.Backend]time.Time
+	busy   map[backend.Backend]bool
+}
+
+// newSchedule returns the schedule of the health checks of a service.
+func newSchedule(healthCheck config.HealthCheck) *schedule {
+	s := &schedule{
+		interval:          time.Duration(healthCheck.Interval) * time.Second,
+		unhealthyInterval: time.Duration(healthCheck.UnhealthyInterval) * time.Second,
+		jitter:            healthCheck.JitterPercent / 100,
+		next:              make(map[backend.Backend]time.Time),
+		busy:              make(map[backend.Backend]bool),
+	}
+	if s.interval <= 0 {
+		s.interval = time.Duration(config.Config.HealthCheckTickerTimeInSeconds) * time.Second
+	}
+	if s.interval <= 0 {
+		s.interval = DefaultInterval
+	}
+	if s.unhealthyInterval <= 0 {
+		s.unhealthyInterval = s.interval
+	}
+	if s.jitter <= 0 {
+		s.jitter = DefaultJitterPercent / 100.0
+	}
+	return s
+}
+
+// due returns the backends whose check is due and marks them busy, the backends already busy are skipped.
+// Backends new to the schedule get their first check at a random time within one interval, or as soon as
+// they are seen when pending, and backends no longer in the pool are forgotten.
+func (s *schedule) due(backends []backend.Backend, now time.Time) []backend.Backend {
+	current := make(map[backend.Backend]bool, len(backends))
+	due := make([]backend.Backend, 0)
+	for _, b := range backends {
+		current[b] = true
+		next, ok := s.next[b]
+		if !ok {
+			next = now.Add(time.Duration(rand.Int64N(int64(s.interval))))
+			if b.IsPending() {
+				next = now
+			}
+			s.next[b] = next
+		}
+		if !next.After(now) && !s.busy[b] {
+			s.busy[b] = true
+			due = append(due, b)
+		}
+	}
+
+	for b := range s.next {
+		if !current[b] {
+			delete(s.next, b)
+		}
+	}
+	return due
+}
+
+// reschedule sets the next check of the backend once its check is over, sooner while it is dead.
+func (s *schedule) reschedule(b backend.Backend, now time.Time) {
+	delete(s.busy, b)
+	interval := s.interval
+	if !b.IsAlive() {
+		interval = s.unhealthyInterval
+	}
+	s.next[b] = now.Add(s.jittered(interval))
+}
+
+// wait returns the time until the next check of a backend not busy is due, at most one interval so new
+// backends are picked up.
+func (s *schedule) wait(now time.Time) time.Duration {
+	wait := s.interval
+	for b, next := range s.next {
+		if !s.busy[b] {
+			wait = min(wait, next.Sub(now))
+		}
+	}
+	return max(wait, 0)
+}
+
+// jittered returns the interval randomly moved by up to the jitter fraction.
+func (s *schedule) jittered(interval time.Duration) time.Duration {
+	return time.Duration(float64(interval) * (1 + s.jitter*(2*rand.Float64()-1)))
+}
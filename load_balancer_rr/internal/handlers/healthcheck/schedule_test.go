@@ -0,0 +1,97 @@
+package healthcheck
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newScheduledBackend() backend.Backend {
+	u, _ := url.Parse("http://localhost:8080")
+	return backend.NewBackendServer(u, nil)
+}
+
+func TestNewSchedule(t *testing.T) {
+	s := newSchedule(config.HealthCheck{Interval: 10, UnhealthyInterval: 2, JitterPercent: 20})
+	assert.Equal(t, 10*time.Second, s.interval)
+	assert.Equal(t, 2*time.Second, s.unhealthyInterval)
+	assert.Equal(t, 0.2, s.jitter)
+
+	// The global interval is the default of the services setting none
+	config.Config.HealthCheckTickerTimeInSeconds = 3
+	s = newSchedule(config.HealthCheck{})
+	assert.Equal(t, 3*time.Second, s.interval)
+	assert.Equal(t, 3*time.Second, s.unhealthyInterval)
+	assert.Equal(t, DefaultJitterPercent/100.0, s.jitter)
+}
+
+func TestSchedule_Due(t *testing.T) {
+	s := newSchedule(config.HealthCheck{Interval: 10})
+	backends := []backend.Backend{newScheduledBackend(), newScheduledBackend(), newScheduledBackend()}
+	now := time.Unix(1700000000, 0)
+
+	// The first checks are spread within one interval
+	s.due(backends, now)
+	for _, b := range backends {
+		assert.False(t, s.next[b].Before(now))
+		assert.True(t, s.next[b].Before(now.Add(10*time.Second)))
+	}
+	assert.Len(t, s.due(backends, now.Add(10*time.Second)), 3)
+
+	// Backends removed from the pool are forgotten
+	s.due(backends[:2], now)
+	assert.Len(t, s.next, 2)
+}
+
+func TestSchedule_Reschedule(t *testing.T) {
+	s := newSchedule(config.HealthCheck{Interval: 10, UnhealthyInterval: 2, JitterPercent: 10})
+	b := newScheduledBackend()
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 100; i++ {
+		s.reschedule(b, now)
+		assert.InDelta(t, float64(10*time.Second), float64(s.next[b].Sub(now)), float64(time.Second))
+	}
+
+	// A dead backend is checked more often
+	b.SetAlive(false)
+	for i := 0; i < 100; i++ {
+		s.reschedule(b, now)
+		assert.InDelta(t, float64(2*time.Second), float64(s.next[b].Sub(now)), float64(200*time.Millisecond))
+	}
+}
+
+func TestSchedule_Wait(t *testing.T) {
+	s := newSchedule(config.HealthCheck{Interval: 10})
+	now := time.Unix(1700000000, 0)
+
+	// Without backends the schedule wakes up every interval to pick up new ones
+	assert.Equal(t, 10*time.Second, s.wait(now))
+
+	first, second := newScheduledBackend(), newScheduledBackend()
+	s.next[first] = now.Add(4 * time.Second)
+	s.next[second] = now.Add(7 * time.Second)
+	assert.Equal(t, 4*time.Second, s.wait(now))
+	assert.Equal(t, time.Duration(0), s.wait(now.Add(5*time.Second)))
+}
+
+func TestSchedule_Busy(t *testing.T) {
+	s := newSchedule(config.HealthCheck{Interval: 10})
+	b := newScheduledBackend()
+	now := time.Unix(1700000000, 0)
+	s.next[b] = now
+
+	// A backend being checked is neither due again nor waited for until it is rescheduled
+	assert.Len(t, s.due([]backend.Backend{b}, now), 1)
+	assert.Empty(t, s.due([]backend.Backend{b}, now.Add(time.Minute)))
+	assert.Equal(t, 10*time.Second, s.wait(now.Add(time.Minute)))
+
+	s.reschedule(b, now)
+	assert.False(t, s.busy[b])
+	assert.Len(t, s.due([]backend.Backend{b}, now.Add(time.Minute)), 1)
+}
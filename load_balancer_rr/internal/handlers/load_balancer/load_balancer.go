@@ -3,7 +3,9 @@ package load_balancer
 import (
 	"net/http"
 
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/sticky_session"
 )
 
 // LoadBalancer defines the interface for a load balancer that can serve HTTP requests.
@@ -11,27 +13,89 @@ type LoadBalancer interface {
 	Serve(http.ResponseWriter, *http.Request)
 }
 
+// refusingBackend is implemented by backends that may refuse a request without answering it,
+// such as a circuit breaker.
+type refusingBackend interface {
+	TryServe(http.ResponseWriter, *http.Request) bool
+}
+
 // loadBalancer is a concrete implementation of the LoadBalancer interface.
 type loadBalancer struct {
-	serverPool serverpool.ServerPool
+	serverPool    serverpool.ServerPool
+	stickySession *sticky_session.StickySession
+}
+
+// Option configures optional behaviour of a loadBalancer.
+type Option func(*loadBalancer)
+
+// WithStickySession routes clients back to the backend pinned by their session cookie while it is alive.
+func WithStickySession(stickySession *sticky_session.StickySession) Option {
+	return func(lb *loadBalancer) {
+		lb.stickySession = stickySession
+	}
 }
 
 // Serve handles incoming HTTP requests by forwarding them to the next available backend server.
 func (lb *loadBalancer) Serve(w http.ResponseWriter, r *http.Request) {
-	// Get the next available backend server from the server pool.
-	backend := lb.serverPool.NextAvailableBackend()
-	if backend != nil {
+	if lb.stickySession != nil {
+		// Route to the pinned backend, falling back to the pool when it is missing, dead, or outside
+		// the priority tier or zone the pool currently selects from, so pins follow failback.
+		if pinned := lb.stickySession.Lookup(r, serverpool.ActiveBackends(lb.serverPool)); pinned != nil {
+			if lb.forward(w, r, pinned) {
+				return
+			}
+		}
+	}
+
+	// Get the next available backend server for the request from the server pool. A backend may refuse
+	// the request without answering it, such as a half open circuit breaker out of trial requests, then
+	// the pool is asked for another one, at most once per backend of the pool.
+	for attempt := int32(1); ; attempt++ {
+		backend := serverpool.SelectBackend(lb.serverPool, r)
+		if backend == nil {
+			break
+		}
 		// If a backend server is available, forward the request to it.
-		backend.Serve(w, r)
-		return
+		if lb.forward(w, r, backend) {
+			return
+		}
+		if attempt >= lb.serverPool.GetServerPoolSize() {
+			break
+		}
 	}
 	// If no backend server is available, respond with a 503 Service Unavailable error.
 	http.Error(w, "Service not available", http.StatusServiceUnavailable)
 }
 
+// forward serves the request with the backend, pinning the client to it for the following requests,
+// or renewing the pin, when sticky sessions are enabled. It reports false when the backend refused
+// the request without answering it.
+func (lb *loadBalancer) forward(w http.ResponseWriter, r *http.Request, b backend.Backend) bool {
+	if lb.stickySession != nil {
+		lb.stickySession.Pin(w, b)
+	}
+
+	refusing, ok := b.(refusingBackend)
+	if !ok {
+		b.Serve(w, r)
+		return true
+	}
+	if refusing.TryServe(w, r) {
+		return true
+	}
+	if lb.stickySession != nil {
+		lb.stickySession.Unpin(w)
+	}
+	return false
+}
+
 // NewLoadBalancer creates a new instance of a load balancer with the specified server pool.
-func NewLoadBalancer(serverPool serverpool.ServerPool) LoadBalancer {
-	return &loadBalancer{
+func NewLoadBalancer(serverPool serverpool.ServerPool, opts ...Option) LoadBalancer {
+	lb := &loadBalancer{
 		serverPool: serverPool,
 	}
+	for _, opt := range opts {
+		opt(lb)
+	}
+	return lb
 }
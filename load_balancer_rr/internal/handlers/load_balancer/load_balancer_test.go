@@ -7,8 +7,12 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/coda-payments/load_balancer_rr/internal/config"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/load_balancer"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/sticky_session"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -55,6 +59,11 @@ func (m *MockServerPool) GetServerPoolSize() int32 {
 	panic("implement me")
 }
 
+func (m *MockServerPool) CountAvailableBackends() int {
+	//TODO implement me
+	panic("implement me")
+}
+
 func TestLoadBalancer_Serve_BackendAvailable(t *testing.T) {
 	// Create a mock backend server.
 	mockBackend := new(MockServer)
@@ -95,3 +104,150 @@ func TestLoadBalancer_Serve_NoBackendAvailable(t *testing.T) {
 	// Assert that the response code is 503 Service Unavailable.
 	assert.Equal(t, http.StatusBadGateway, rr.Code)
 }
+
+func TestLoadBalancer_Serve_StickySession(t *testing.T) {
+	// Create two backend servers that identify themselves in the response.
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+	}
+	first, second := newServer("first"), newServer("second")
+	defer first.Close()
+	defer second.Close()
+
+	pool := round_robin.Initialize()
+	backends := map[string]backend.Backend{}
+	for name, server := range map[string]*httptest.Server{"first": first, "second": second} {
+		parsedURL, _ := url.Parse(server.URL)
+		b := backend.NewBackendServer(parsedURL, httputil.NewSingleHostReverseProxy(parsedURL))
+		backends[name] = b
+		pool.RegisterServiceBackend(b)
+	}
+
+	stickySession, err := sticky_session.New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	assert.NoError(t, err)
+	lb := load_balancer.NewLoadBalancer(pool, load_balancer.WithStickySession(stickySession))
+
+	// The first request is balanced and pins the client with a cookie.
+	rr := httptest.NewRecorder()
+	lb.Serve(rr, httptest.NewRequest(http.MethodPost, "/create", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	pinnedTo := rr.Body.String()
+	cookies := rr.Result().Cookies()
+	assert.Len(t, cookies, 1)
+
+	// Following requests with the cookie stick to the same backend and renew the cookie.
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/create", nil)
+		req.AddCookie(cookies[0])
+		rr := httptest.NewRecorder()
+		lb.Serve(rr, req)
+		assert.Equal(t, pinnedTo, rr.Body.String())
+		renewed := rr.Result().Cookies()
+		assert.Len(t, renewed, 1)
+		assert.Equal(t, cookies[0].Name, renewed[0].Name)
+	}
+
+	// Once the pinned backend is dead the request falls back to the pool and is pinned again.
+	backends[pinnedTo].SetAlive(false)
+	req := httptest.NewRequest(http.MethodPost, "/create", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	lb.Serve(rr, req)
+	assert.NotEqual(t, pinnedTo, rr.Body.String())
+	assert.Len(t, rr.Result().Cookies(), 1)
+}
+
+func TestLoadBalancer_Serve_StickySessionFailback(t *testing.T) {
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+	}
+	primaryServer, backupServer := newServer("primary"), newServer("backup")
+	defer primaryServer.Close()
+	defer backupServer.Close()
+
+	newBackend := func(server *httptest.Server, priority int) backend.Backend {
+		parsedURL, _ := url.Parse(server.URL)
+		return backend.NewBackendServer(parsedURL, httputil.NewSingleHostReverseProxy(parsedURL), backend.WithPriority(priority))
+	}
+	primary, backup := newBackend(primaryServer, 0), newBackend(backupServer, 1)
+	pool := serverpool.NewPriorityPool(func() serverpool.ServerPool { return round_robin.Initialize() }, 1)
+	pool.RegisterServiceBackend(primary)
+	pool.RegisterServiceBackend(backup)
+
+	stickySession, err := sticky_session.New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	assert.NoError(t, err)
+	lb := load_balancer.NewLoadBalancer(pool, load_balancer.WithStickySession(stickySession))
+
+	// The client is pinned to the backup tier while the primary is dead.
+	primary.SetAlive(false)
+	rr := httptest.NewRecorder()
+	lb.Serve(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "backup", rr.Body.String())
+	cookies := rr.Result().Cookies()
+	assert.Len(t, cookies, 1)
+
+	// Once the primary recovers the pin to the backup is no longer honoured nor renewed.
+	primary.SetAlive(true)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	lb.Serve(rr, req)
+	assert.Equal(t, "primary", rr.Body.String())
+	repinned := rr.Result().Cookies()
+	assert.Len(t, repinned, 1)
+	assert.NotEqual(t, cookies[0].Value, repinned[0].Value)
+}
+
+// refusingBackend is available but refuses every request, as a half open circuit breaker whose
+// trial requests were taken since it was selected.
+type refusingBackend struct {
+	backend.Backend
+	refused int
+}
+
+func (b *refusingBackend) TryServe(http.ResponseWriter, *http.Request) bool {
+	b.refused++
+	return false
+}
+
+func TestLoadBalancer_Serve_BackendRefuses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("served"))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	refusedURL, _ := url.Parse("http://localhost:8085")
+	refusing := &refusingBackend{Backend: backend.NewBackendServer(refusedURL, nil)}
+	serving := backend.NewBackendServer(serverURL, httputil.NewSingleHostReverseProxy(serverURL))
+	pool := round_robin.Initialize()
+	pool.RegisterServiceBackend(refusing)
+	pool.RegisterServiceBackend(serving)
+
+	stickySession, err := sticky_session.New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	assert.NoError(t, err)
+	lb := load_balancer.NewLoadBalancer(pool, load_balancer.WithStickySession(stickySession))
+
+	// Every request is served by the other backend, which the client is pinned to
+	for i := 0; i < 4; i++ {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: sticky_session.DefaultCookieName, Value: "invalid"})
+		lb.Serve(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "served", rr.Body.String())
+		assert.Len(t, rr.Result().Cookies(), 1)
+	}
+	assert.Positive(t, refusing.refused)
+
+	// When every backend refuses, the request is answered with a 503
+	serving.SetAlive(false)
+	rr := httptest.NewRecorder()
+	lb.Serve(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Empty(t, rr.Result().Cookies())
+}
@@ -0,0 +1,192 @@
+package outlier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+	"github.com/coda-payments/load_balancer_rr/pkg/utils"
+)
+
+const (
+	// DefaultWindow is the length of the error rate window.
+	DefaultWindow = 10 * time.Second
+	// DefaultMinRequests is the number of requests a window needs for its error rate to count.
+	DefaultMinRequests = 20
+	// DefaultBaseEjectionTime is the ejection time of a backend ejected for the first time.
+	DefaultBaseEjectionTime = 30 * time.Second
+	// DefaultMaxEjectionTime caps the ejection time of a backend ejected repeatedly.
+	DefaultMaxEjectionTime = 300 * time.Second
+	// DefaultMaxEjectionPercent caps the share of the pool ejected at once.
+	DefaultMaxEjectionPercent = 10
+)
+
+// Detector ejects the backends of a pool failing the proxied requests, passively, from the responses
+// of the real traffic. A backend is ejected after consecutive 5xx responses or proxy errors, or when
+// its error rate over a window is too high. Each ejection of the same backend lasts longer than the
+// previous one, and the backend is back in rotation once it ends.
+type Detector struct {
+	pool               serverpool.ServerPool
+	consecutiveErrors  int
+	errorRate          float64
+	window             time.Duration
+	minRequests        int
+	baseEjectionTime   time.Duration
+	maxEjectionTime    time.Duration
+	maxEjectionPercent float64
+
+	mux   sync.Mutex
+	stats map[backend.Backend]*stats
+	// pruned is the last time the stats of the backends no longer in the pool were dropped
+	pruned time.Time
+	now    func() time.Time
+}
+
+// stats are the outcomes of the requests proxied to a backend.
+type stats struct {
+	consecutiveErrors int
+	// windowStart, requests and failures describe the current error rate window
+	windowStart time.Time
+	requests    int
+	failures    int
+	// ejections is the multiplier of the ejection time, it decays by one for each window without ejection
+	ejections       int
+	ejectedInWindow bool
+}
+
+// New initializes and returns a new Detector for the pool, or nil when outlier detection is disabled.
+func New(outlierDetection config.OutlierDetection, pool serverpool.ServerPool) *Detector {
+	if outlierDetection.ConsecutiveErrors <= 0 && outlierDetection.ErrorRate <= 0 {
+		return nil
+	}
+
+	d := &Detector{
+		pool:               pool,
+		consecutiveErrors:  outlierDetection.ConsecutiveErrors,
+		errorRate:          outlierDetection.ErrorRate,
+		window:             utils.SecondsOrDefault(outlierDetection.Window, DefaultWindow),
+		minRequests:        outlierDetection.MinRequests,
+		baseEjectionTime:   utils.SecondsOrDefault(outlierDetection.BaseEjectionTime, DefaultBaseEjectionTime),
+		maxEjectionTime:    utils.SecondsOrDefault(outlierDetection.MaxEjectionTime, DefaultMaxEjectionTime),
+		maxEjectionPercent: outlierDetection.MaxEjectionPercent,
+		stats:              make(map[backend.Backend]*stats),
+		now:                time.Now,
+	}
+	if d.minRequests <= 0 {
+		d.minRequests = DefaultMinRequests
+	}
+	if d.maxEjectionPercent <= 0 {
+		d.maxEjectionPercent = DefaultMaxEjectionPercent
+	}
+	return d
+}
+
+// ModifyResponse returns the httputil.ReverseProxy ModifyResponse hook recording the responses of the backend,
+// 5xx responses count as failures. The backend must be the one registered in the pool, or its stats are dropped.
+func (d *Detector) ModifyResponse(b backend.Backend) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		d.Record(b, resp.StatusCode >= http.StatusInternalServerError)
+		return nil
+	}
+}
+
+// ErrorHandler returns the httputil.ReverseProxy ErrorHandler hook recording the proxy errors of the backend,
+// requests cancelled by the client are not the fault of the backend and are not recorded. The backend must be
+// the one registered in the pool, as for ModifyResponse.
+func (d *Detector) ErrorHandler(b backend.Backend) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		if !errors.Is(err, context.Canceled) {
+			d.Record(b, true)
+		}
+		backend.ProxyErrorHandler(w, r, err)
+	}
+}
+
+// Record adds the outcome of a request proxied to the backend, ejecting the backend when it became an outlier.
+func (d *Detector) Record(b backend.Backend, failed bool) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	now := d.now()
+	if now.Sub(d.pruned) >= d.window {
+		d.prune(now)
+	}
+	s, ok := d.stats[b]
+	if !ok {
+		s = &stats{windowStart: now}
+		d.stats[b] = s
+	}
+	if now.Sub(s.windowStart) >= d.window {
+		if !s.ejectedInWindow && s.ejections > 0 {
+			s.ejections--
+		}
+		s.windowStart, s.requests, s.failures, s.ejectedInWindow = now, 0, 0, false
+	}
+
+	// Requests finishing on an ejected backend were sent before the ejection
+	if b.IsEjected() {
+		return
+	}
+
+	s.requests++
+	if !failed {
+		s.consecutiveErrors = 0
+		return
+	}
+	s.failures++
+	s.consecutiveErrors++
+
+	consecutive := d.consecutiveErrors > 0 && s.consecutiveErrors >= d.consecutiveErrors
+	rate := d.errorRate > 0 && s.requests >= d.minRequests &&
+		float64(s.failures)*100/float64(s.requests) >= d.errorRate
+	if (consecutive || rate) && d.canEject() {
+		d.eject(b, s, now)
+	}
+}
+
+// prune drops the stats of the backends removed from the pool, once per window.
+func (d *Detector) prune(now time.Time) {
+	current := make(map[backend.Backend]bool)
+	for _, b := range d.pool.ListServiceBackends() {
+		current[b] = true
+	}
+	for b := range d.stats {
+		if !current[b] {
+			delete(d.stats, b)
+		}
+	}
+	d.pruned = now
+}
+
+// canEject reports whether one more backend of the pool can be ejected without going over the maximum share.
+func (d *Detector) canEject() bool {
+	backends := d.pool.ListServiceBackends()
+	ejected := 0
+	for _, b := range backends {
+		if b.IsEjected() {
+			ejected++
+		}
+	}
+	return ejected == 0 || float64(ejected+1)*100 <= d.maxEjectionPercent*float64(len(backends))
+}
+
+// eject takes the backend out of rotation for the base ejection time multiplied by its number of ejections.
+func (d *Detector) eject(b backend.Backend, s *stats, now time.Time) {
+	s.ejections++
+	s.ejectedInWindow = true
+	s.consecutiveErrors, s.requests, s.failures = 0, 0, 0
+
+	ejectionTime := min(d.baseEjectionTime*time.Duration(s.ejections), d.maxEjectionTime)
+	b.Eject(now.Add(ejectionTime))
+
+	// Push alert here: outlier backend ejected
+	config.Logger.Warn("ejected outlier backend", zap.String("host", b.GetURL().String()),
+		zap.Duration("ejectionTime", ejectionTime), zap.Int("ejections", s.ejections))
+}
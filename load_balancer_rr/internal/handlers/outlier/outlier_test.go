@@ -0,0 +1,226 @@
+package outlier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+)
+
+// MockBackend is a mock implementation of the backend.Backend interface whose ejection follows the test clock.
+type MockBackend struct {
+	// Backend satisfies the methods the detector does not rely on
+	backend.Backend
+	url          *url.URL
+	clock        *time.Time
+	ejectedUntil time.Time
+}
+
+func (m *MockBackend) GetURL() *url.URL {
+	return m.url
+}
+
+func (m *MockBackend) Eject(until time.Time) {
+	m.ejectedUntil = until
+}
+
+func (m *MockBackend) IsEjected() bool {
+	return m.clock.Before(m.ejectedUntil)
+}
+
+// newDetector returns a detector over a pool of n mock backends, driven by the returned clock.
+func newDetector(outlierDetection config.OutlierDetection, n int) (*Detector, []*MockBackend, *time.Time) {
+	clock := time.Unix(1700000000, 0)
+	pool := round_robin.Initialize()
+	backends := make([]*MockBackend, 0, n)
+	for i := 0; i < n; i++ {
+		u, _ := url.Parse(fmt.Sprintf("http://localhost:%d", 8080+i))
+		b := &MockBackend{url: u, clock: &clock}
+		backends = append(backends, b)
+		pool.RegisterServiceBackend(b)
+	}
+
+	d := New(outlierDetection, pool)
+	d.now = func() time.Time { return clock }
+	return d, backends, &clock
+}
+
+func record(d *Detector, b backend.Backend, failed bool, times int) {
+	for i := 0; i < times; i++ {
+		d.Record(b, failed)
+	}
+}
+
+func TestNew(t *testing.T) {
+	assert.Nil(t, New(config.OutlierDetection{}, round_robin.Initialize()))
+
+	d := New(config.OutlierDetection{ConsecutiveErrors: 5}, round_robin.Initialize())
+	assert.Equal(t, DefaultWindow, d.window)
+	assert.Equal(t, DefaultMinRequests, d.minRequests)
+	assert.Equal(t, DefaultBaseEjectionTime, d.baseEjectionTime)
+	assert.Equal(t, DefaultMaxEjectionTime, d.maxEjectionTime)
+	assert.Equal(t, float64(DefaultMaxEjectionPercent), d.maxEjectionPercent)
+}
+
+func TestDetector_ConsecutiveErrors(t *testing.T) {
+	d, backends, clock := newDetector(config.OutlierDetection{ConsecutiveErrors: 3, MaxEjectionPercent: 100}, 2)
+
+	// A success in between resets the consecutive errors
+	record(d, backends[0], true, 2)
+	d.Record(backends[0], false)
+	record(d, backends[0], true, 2)
+	assert.False(t, backends[0].IsEjected())
+
+	d.Record(backends[0], true)
+	assert.True(t, backends[0].IsEjected())
+	assert.Equal(t, clock.Add(DefaultBaseEjectionTime), backends[0].ejectedUntil)
+	assert.False(t, backends[1].IsEjected())
+}
+
+func TestDetector_ErrorRate(t *testing.T) {
+	d, backends, _ := newDetector(config.OutlierDetection{ErrorRate: 50, MinRequests: 10, MaxEjectionPercent: 100}, 2)
+
+	// Not enough requests in the window for the error rate to count
+	record(d, backends[0], true, 5)
+	assert.False(t, backends[0].IsEjected())
+
+	record(d, backends[0], false, 4)
+	assert.False(t, backends[0].IsEjected())
+
+	d.Record(backends[0], false)
+	d.Record(backends[0], true)
+	assert.True(t, backends[0].IsEjected())
+}
+
+func TestDetector_ErrorRateWindow(t *testing.T) {
+	d, backends, clock := newDetector(config.OutlierDetection{ErrorRate: 50, MinRequests: 10, MaxEjectionPercent: 100}, 2)
+
+	record(d, backends[0], true, 5)
+	*clock = clock.Add(DefaultWindow)
+
+	// The failures of the previous window are forgotten
+	record(d, backends[0], false, 9)
+	d.Record(backends[0], true)
+	assert.False(t, backends[0].IsEjected())
+}
+
+func TestDetector_EjectionTimeGrows(t *testing.T) {
+	d, backends, clock := newDetector(config.OutlierDetection{
+		ConsecutiveErrors:  1,
+		BaseEjectionTime:   10,
+		MaxEjectionTime:    25,
+		MaxEjectionPercent: 100,
+	}, 1)
+
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 25 * time.Second}
+	for _, ejectionTime := range expected {
+		d.Record(backends[0], true)
+		assert.Equal(t, clock.Add(ejectionTime), backends[0].ejectedUntil)
+
+		// Requests finishing while the backend is ejected are ignored
+		d.Record(backends[0], true)
+		assert.Equal(t, clock.Add(ejectionTime), backends[0].ejectedUntil)
+
+		*clock = backends[0].ejectedUntil
+	}
+
+	// The multiplier decays by one for each window without ejection, once the window of the last ejection is over
+	for i := 0; i < 3; i++ {
+		*clock = clock.Add(DefaultWindow)
+		d.Record(backends[0], false)
+	}
+	d.Record(backends[0], true)
+	assert.Equal(t, clock.Add(20*time.Second), backends[0].ejectedUntil)
+}
+
+func TestDetector_MaxEjectionPercent(t *testing.T) {
+	d, backends, _ := newDetector(config.OutlierDetection{ConsecutiveErrors: 1, MaxEjectionPercent: 50}, 4)
+
+	for _, b := range backends {
+		d.Record(b, true)
+	}
+
+	ejected := 0
+	for _, b := range backends {
+		if b.IsEjected() {
+			ejected++
+		}
+	}
+	assert.Equal(t, 2, ejected)
+
+	// One backend can always be ejected, even when it is over the maximum share
+	d, backends, _ = newDetector(config.OutlierDetection{ConsecutiveErrors: 1}, 2)
+	d.Record(backends[0], true)
+	d.Record(backends[1], true)
+	assert.True(t, backends[0].IsEjected())
+	assert.False(t, backends[1].IsEjected())
+}
+
+func TestDetector_ReverseProxyHooks(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	b := backend.NewBackendServer(u, proxy)
+	pool := round_robin.Initialize()
+	pool.RegisterServiceBackend(b)
+
+	d := New(config.OutlierDetection{ConsecutiveErrors: 2}, pool)
+	proxy.ModifyResponse = d.ModifyResponse(b)
+	proxy.ErrorHandler = d.ErrorHandler(b)
+
+	rr := httptest.NewRecorder()
+	b.Serve(rr, httptest.NewRequest(http.MethodGet, "/create", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.False(t, b.IsEjected())
+
+	// A proxy error counts as a failure and answers with a 502
+	server.Close()
+	rr = httptest.NewRecorder()
+	b.Serve(rr, httptest.NewRequest(http.MethodGet, "/create", nil))
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+	assert.True(t, b.IsEjected())
+	assert.False(t, b.IsAvailable())
+}
+
+func TestDetector_ErrorHandlerCancelled(t *testing.T) {
+	d, backends, _ := newDetector(config.OutlierDetection{ConsecutiveErrors: 1}, 1)
+
+	req := httptest.NewRequest(http.MethodGet, "/create", nil)
+	d.ErrorHandler(backends[0])(httptest.NewRecorder(), req, context.Canceled)
+	assert.False(t, backends[0].IsEjected())
+
+	d.ErrorHandler(backends[0])(httptest.NewRecorder(), req, fmt.Errorf("dial tcp: connection refused"))
+	assert.True(t, backends[0].IsEjected())
+}
+
+func TestDetector_ForgetsRemovedBackends(t *testing.T) {
+	d, backends, clock := newDetector(config.OutlierDetection{ConsecutiveErrors: 3, MaxEjectionPercent: 100}, 2)
+	record(d, backends[0], false, 1)
+	record(d, backends[1], false, 1)
+	assert.Len(t, d.stats, 2)
+
+	d.pool.RemoveBackend(backends[1])
+	record(d, backends[0], false, 1)
+	assert.Len(t, d.stats, 2)
+
+	// The stats of the removed backend are dropped once the window rolls over
+	*clock = clock.Add(DefaultWindow)
+	record(d, backends[0], false, 1)
+	assert.Len(t, d.stats, 1)
+	assert.Contains(t, d.stats, backend.Backend(backends[0]))
+}
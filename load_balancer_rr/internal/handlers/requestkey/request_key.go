@@ -0,0 +1,53 @@
+package requestkey
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/constant"
+)
+
+// Func extracts the routing key of a request, it returns an empty string when the request has none.
+type Func func(r *http.Request) string
+
+// New returns the key extractor for the configured request key, defaulting to the client IP.
+func New(key config.RequestKey) (Func, error) {
+	if key.Source != "" && key.Source != constant.KeySourceIP && key.Name == "" {
+		return nil, errors.New("request key name is required for source " + key.Source)
+	}
+
+	switch key.Source {
+	case "", constant.KeySourceIP:
+		return ClientIP, nil
+	case constant.KeySourceHeader:
+		return func(r *http.Request) string {
+			return r.Header.Get(key.Name)
+		}, nil
+	case constant.KeySourceCookie:
+		return func(r *http.Request) string {
+			cookie, err := r.Cookie(key.Name)
+			if err != nil {
+				return ""
+			}
+			return cookie.Value
+		}, nil
+	case constant.KeySourceQuery:
+		return func(r *http.Request) string {
+			return r.URL.Query().Get(key.Name)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported request key source: %q", key.Source)
+	}
+}
+
+// ClientIP returns the IP address of the client that sent the request.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
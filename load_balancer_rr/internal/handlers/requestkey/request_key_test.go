@@ -0,0 +1,56 @@
+package requestkey
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/constant"
+)
+
+func TestNew(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/create?gamerID=from-query", nil)
+	req.RemoteAddr = "10.0.0.7:52311"
+	req.Header.Set("X-Gamer-Id", "from-header")
+	req.AddCookie(&http.Cookie{Name: "gamerID", Value: "from-cookie"})
+
+	tests := []struct {
+		name      string
+		key       config.RequestKey
+		expected  string
+		expectErr bool
+	}{
+		{name: "default client ip", key: config.RequestKey{}, expected: "10.0.0.7"},
+		{name: "client ip", key: config.RequestKey{Source: constant.KeySourceIP}, expected: "10.0.0.7"},
+		{name: "header", key: config.RequestKey{Source: constant.KeySourceHeader, Name: "X-Gamer-Id"}, expected: "from-header"},
+		{name: "cookie", key: config.RequestKey{Source: constant.KeySourceCookie, Name: "gamerID"}, expected: "from-cookie"},
+		{name: "query", key: config.RequestKey{Source: constant.KeySourceQuery, Name: "gamerID"}, expected: "from-query"},
+		{name: "missing cookie", key: config.RequestKey{Source: constant.KeySourceCookie, Name: "session"}, expected: ""},
+		{name: "missing name", key: config.RequestKey{Source: constant.KeySourceHeader}, expectErr: true},
+		{name: "unknown source", key: config.RequestKey{Source: "body", Name: "gamerID"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyFunc, err := New(tt.key)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, keyFunc(req))
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "[::1]:8080"
+	assert.Equal(t, "::1", ClientIP(req))
+
+	req.RemoteAddr = "10.0.0.7"
+	assert.Equal(t, "10.0.0.7", ClientIP(req))
+}
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/requestkey"
+)
+
+// canaryBuckets is the resolution of the split, a hundredth of a percent.
+const canaryBuckets = 10000
+
+// Canary splits off a percentage of the requests of a route to a canary route.
+// The percentage is stored atomically so it can be changed while serving traffic.
+type Canary struct {
+	route *Route
+	// basisPoints is the share of requests sent to the canary, in hundredths of a percent
+	basisPoints atomic.Uint32
+	// keyFunc assigns requests to the canary by key when the split is sticky, nil otherwise
+	keyFunc requestkey.Func
+}
+
+// NewCanary initializes and returns a new Canary sending the configured share of requests to the route.
+func NewCanary(canary config.Canary, route *Route) (*Canary, error) {
+	c := &Canary{route: route}
+	if err := c.SetPercent(canary.Percent); err != nil {
+		return nil, err
+	}
+
+	if canary.Sticky {
+		keyFunc, err := requestkey.New(canary.Key)
+		if err != nil {
+			return nil, err
+		}
+		c.keyFunc = keyFunc
+	}
+	return c, nil
+}
+
+// Route returns the canary route.
+func (c *Canary) Route() *Route {
+	return c.route
+}
+
+// Percent returns the share of requests currently sent to the canary.
+func (c *Canary) Percent() float64 {
+	return float64(c.basisPoints.Load()) * 100 / canaryBuckets
+}
+
+// SetPercent changes the share of requests sent to the canary.
+func (c *Canary) SetPercent(percent float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100, got %v", percent)
+	}
+	c.basisPoints.Store(uint32(percent * canaryBuckets / 100))
+	return nil
+}
+
+// Selects reports whether the request goes to the canary. Sticky splits place each key in a
+// fixed bucket, so the same key keeps going to the same version while the percentage is unchanged,
+// and raising the percentage only moves keys towards the canary.
+func (c *Canary) Selects(r *http.Request) bool {
+	basisPoints := c.basisPoints.Load()
+	if basisPoints == 0 {
+		return false
+	}
+
+	if c.keyFunc != nil {
+		if key := c.keyFunc(r); key != "" {
+			return bucket(key) < basisPoints
+		}
+	}
+	return uint32(rand.IntN(canaryBuckets)) < basisPoints
+}
+
+// bucket hashes the key into one of the canary buckets.
+func bucket(key string) uint32 {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(key))
+	return hasher.Sum32() % canaryBuckets
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/constant"
+)
+
+func newGamerRequest(gamerID string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/create", nil)
+	req.Header.Set("X-Gamer-Id", gamerID)
+	return req
+}
+
+func TestNewCanary(t *testing.T) {
+	canaryRoute := newRoute("game-canary", "", "")
+
+	canary, err := NewCanary(config.Canary{Service: "game-canary", Percent: 5}, canaryRoute)
+	assert.NoError(t, err)
+	assert.Equal(t, canaryRoute, canary.Route())
+	assert.Equal(t, 5.0, canary.Percent())
+	assert.Nil(t, canary.keyFunc)
+
+	_, err = NewCanary(config.Canary{Service: "game-canary", Percent: 120}, canaryRoute)
+	assert.Error(t, err)
+
+	_, err = NewCanary(config.Canary{Service: "game-canary", Sticky: true, Key: config.RequestKey{Source: constant.KeySourceHeader}}, canaryRoute)
+	assert.Error(t, err)
+}
+
+func TestCanary_SetPercent(t *testing.T) {
+	canary, _ := NewCanary(config.Canary{Percent: 5}, newRoute("game-canary", "", ""))
+
+	assert.NoError(t, canary.SetPercent(12.5))
+	assert.Equal(t, 12.5, canary.Percent())
+	assert.Error(t, canary.SetPercent(-1))
+	assert.Equal(t, 12.5, canary.Percent())
+}
+
+func TestCanary_Selects(t *testing.T) {
+	canary, _ := NewCanary(config.Canary{Percent: 0}, newRoute("game-canary", "", ""))
+	for i := 0; i < 100; i++ {
+		assert.False(t, canary.Selects(newGamerRequest("GYUTDTE")))
+	}
+
+	assert.NoError(t, canary.SetPercent(100))
+	for i := 0; i < 100; i++ {
+		assert.True(t, canary.Selects(newGamerRequest("GYUTDTE")))
+	}
+
+	assert.NoError(t, canary.SetPercent(20))
+	selected := 0
+	for i := 0; i < 5000; i++ {
+		if canary.Selects(newGamerRequest("GYUTDTE")) {
+			selected++
+		}
+	}
+	assert.InDelta(t, 1000, selected, 150)
+}
+
+func TestCanary_SelectsSticky(t *testing.T) {
+	canary, err := NewCanary(config.Canary{
+		Percent: 10,
+		Sticky:  true,
+		Key:     config.RequestKey{Source: constant.KeySourceHeader, Name: "X-Gamer-Id"},
+	}, newRoute("game-canary", "", ""))
+	assert.NoError(t, err)
+
+	assigned := map[string]bool{}
+	selected := 0
+	for i := 0; i < 5000; i++ {
+		gamerID := fmt.Sprintf("gamer-%d", i)
+		assigned[gamerID] = canary.Selects(newGamerRequest(gamerID))
+		if assigned[gamerID] {
+			selected++
+		}
+	}
+	assert.InDelta(t, 500, selected, 100)
+
+	// A user keeps the same version across requests
+	for gamerID, wasSelected := range assigned {
+		assert.Equal(t, wasSelected, canary.Selects(newGamerRequest(gamerID)))
+	}
+
+	// Raising the percentage only moves users to the canary
+	assert.NoError(t, canary.SetPercent(30))
+	for gamerID, wasSelected := range assigned {
+		if wasSelected {
+			assert.True(t, canary.Selects(newGamerRequest(gamerID)))
+		}
+	}
+}
+
+func TestRouter_ServeCanary(t *testing.T) {
+	rt := NewRouter()
+	game := newRoute("game", "/", "")
+	canaryRoute := newRoute("game-canary", "/canary", "")
+	rt.AddRoute(game)
+	rt.AddRoute(canaryRoute)
+
+	canary, _ := NewCanary(config.Canary{Percent: 100}, canaryRoute)
+	game.Canary = canary
+	assert.Equal(t, "game-canary", serve(rt, "lb.example.com", "/create").Body.String())
+
+	assert.NoError(t, canary.SetPercent(0))
+	assert.Equal(t, "game", serve(rt, "lb.example.com", "/create").Body.String())
+
+	assert.Equal(t, canaryRoute, rt.Route("game-canary"))
+	assert.Nil(t, rt.Route("unknown"))
+}
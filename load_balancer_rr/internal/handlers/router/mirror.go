@@ -0,0 +1,125 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+const (
+	// MaxMirrorBodyBytes is the largest request body buffered for mirroring, larger requests are not mirrored.
+	MaxMirrorBodyBytes = 1 << 20
+	// MaxMirrorInFlight bounds the mirrored requests in flight, requests are not mirrored past it.
+	MaxMirrorInFlight = 100
+	// MirrorTimeout bounds a mirrored request, which outlives the request it copies.
+	MirrorTimeout = 30 * time.Second
+)
+
+// Mirror shadows a share of the requests of a route to a mirror route. Mirrored requests are sent
+// asynchronously and their responses are discarded, so they never affect the primary request.
+type Mirror struct {
+	route *Route
+	// percent is the share of requests mirrored
+	percent float64
+	// inFlight bounds the mirrored requests in flight
+	inFlight chan struct{}
+}
+
+// NewMirror initializes and returns a new Mirror sending the configured share of requests to the route.
+func NewMirror(mirror config.Mirror, route *Route) (*Mirror, error) {
+	if mirror.Percent <= 0 || mirror.Percent > 100 {
+		return nil, fmt.Errorf("mirror percent must be between 0 and 100, got %v", mirror.Percent)
+	}
+	return &Mirror{
+		route:    route,
+		percent:  mirror.Percent,
+		inFlight: make(chan struct{}, MaxMirrorInFlight),
+	}, nil
+}
+
+// Route returns the mirror route.
+func (m *Mirror) Route() *Route {
+	return m.route
+}
+
+// Send copies the request to the mirror when it is sampled. The request body is buffered so both
+// the primary and the mirror read all of it; r.Body is replaced and stays readable by the caller.
+func (m *Mirror) Send(r *http.Request) {
+	if m.percent < 100 && rand.Float64()*100 >= m.percent {
+		return
+	}
+
+	// Skip the mirror rather than queue requests when it falls behind
+	select {
+	case m.inFlight <- struct{}{}:
+	default:
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), MirrorTimeout)
+	shadow, ok := m.shadow(ctx, r)
+	if !ok {
+		cancel()
+		<-m.inFlight
+		return
+	}
+
+	go func() {
+		defer func() { <-m.inFlight }()
+		defer cancel()
+		m.route.LoadBalancer.Serve(newDiscardResponseWriter(), shadow)
+	}()
+}
+
+// shadow returns a copy of the request with the context to send to the mirror. It reports false
+// when the body is too large or cannot be read to mirror.
+func (m *Mirror) shadow(ctx context.Context, r *http.Request) (*http.Request, bool) {
+	shadow := r.Clone(ctx)
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return shadow, true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxMirrorBodyBytes+1))
+	if err != nil || len(body) > MaxMirrorBodyBytes {
+		// Hand the primary request what was read followed by the rest of the body
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		return nil, false
+	}
+
+	r.Body = readCloser{Reader: bytes.NewReader(body), Closer: r.Body}
+	shadow.Body = io.NopCloser(bytes.NewReader(body))
+	shadow.ContentLength = int64(len(body))
+	return shadow, true
+}
+
+// readCloser reads from a buffered body while closing the original one.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// discardResponseWriter is a http.ResponseWriter throwing the mirror responses away.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func newDiscardResponseWriter() *discardResponseWriter {
+	return &discardResponseWriter{header: make(http.Header)}
+}
+
+func (d *discardResponseWriter) Header() http.Header {
+	return d.header
+}
+
+func (d *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (d *discardResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,140 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+// RecordingLoadBalancer is a mock implementation of the load_balancer.LoadBalancer interface
+// recording the body of the requests it serves.
+type RecordingLoadBalancer struct {
+	mu      sync.Mutex
+	bodies  []string
+	release chan struct{}
+}
+
+func (m *RecordingLoadBalancer) Serve(w http.ResponseWriter, r *http.Request) {
+	if m.release != nil {
+		<-m.release
+	}
+	body, _ := io.ReadAll(r.Body)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bodies = append(m.bodies, string(body))
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+func (m *RecordingLoadBalancer) served() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.bodies...)
+}
+
+func newMirrorRoute(lb *RecordingLoadBalancer) *Route {
+	return &Route{Service: config.Service{Name: "game-next"}, LoadBalancer: lb}
+}
+
+func TestNewMirror(t *testing.T) {
+	mirrorRoute := newMirrorRoute(&RecordingLoadBalancer{})
+
+	mirror, err := NewMirror(config.Mirror{Service: "game-next", Percent: 100}, mirrorRoute)
+	assert.NoError(t, err)
+	assert.Equal(t, mirrorRoute, mirror.Route())
+
+	_, err = NewMirror(config.Mirror{Service: "game-next"}, mirrorRoute)
+	assert.Error(t, err)
+
+	_, err = NewMirror(config.Mirror{Service: "game-next", Percent: 101}, mirrorRoute)
+	assert.Error(t, err)
+}
+
+func TestMirror_Send(t *testing.T) {
+	lb := &RecordingLoadBalancer{}
+	mirror, _ := NewMirror(config.Mirror{Service: "game-next", Percent: 100}, newMirrorRoute(lb))
+
+	req := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{"gamerID":"GYUTDTE"}`))
+	mirror.Send(req)
+
+	// The primary request still reads the whole body
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"gamerID":"GYUTDTE"}`, string(body))
+
+	assert.Eventually(t, func() bool {
+		return len(lb.served()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{`{"gamerID":"GYUTDTE"}`}, lb.served())
+}
+
+func TestMirror_SendLargeBody(t *testing.T) {
+	lb := &RecordingLoadBalancer{}
+	mirror, _ := NewMirror(config.Mirror{Service: "game-next", Percent: 100}, newMirrorRoute(lb))
+
+	large := strings.Repeat("x", MaxMirrorBodyBytes+10)
+	req := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(large))
+	mirror.Send(req)
+
+	body, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(body))
+	assert.Empty(t, lb.served())
+	assert.Empty(t, mirror.inFlight)
+}
+
+func TestMirror_SendSampled(t *testing.T) {
+	lb := &RecordingLoadBalancer{}
+	mirror, _ := NewMirror(config.Mirror{Service: "game-next", Percent: 20}, newMirrorRoute(lb))
+
+	// Send in batches below the in flight bound so no sampled request is dropped
+	for batch := 0; batch < 40; batch++ {
+		for i := 0; i < 50; i++ {
+			mirror.Send(httptest.NewRequest(http.MethodGet, "/create", nil))
+		}
+		assert.Eventually(t, func() bool {
+			return len(mirror.inFlight) == 0
+		}, time.Second, time.Millisecond)
+	}
+	assert.InDelta(t, 400, len(lb.served()), 100)
+}
+
+func TestMirror_SendBounded(t *testing.T) {
+	lb := &RecordingLoadBalancer{release: make(chan struct{})}
+	mirror, _ := NewMirror(config.Mirror{Service: "game-next", Percent: 100}, newMirrorRoute(lb))
+
+	for i := 0; i < MaxMirrorInFlight+10; i++ {
+		mirror.Send(httptest.NewRequest(http.MethodGet, "/create", nil))
+	}
+	assert.Len(t, mirror.inFlight, MaxMirrorInFlight)
+
+	close(lb.release)
+	assert.Eventually(t, func() bool {
+		return len(lb.served()) == MaxMirrorInFlight
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRouter_ServeMirror(t *testing.T) {
+	rt := NewRouter()
+	game := newRoute("game", "/", "")
+	rt.AddRoute(game)
+
+	lb := &RecordingLoadBalancer{}
+	mirror, _ := NewMirror(config.Mirror{Service: "game-next", Percent: 100}, newMirrorRoute(lb))
+	game.Mirror = mirror
+
+	// The primary response is unaffected by the failing mirror
+	rr := serve(rt, "lb.example.com", "/create")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "game", rr.Body.String())
+	assert.Eventually(t, func() bool {
+		return len(lb.served()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
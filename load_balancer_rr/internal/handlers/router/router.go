@@ -0,0 +1,166 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/load_balancer"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+)
+
+// Route binds a service to its server pool and the load balancer serving its requests.
+type Route struct {
+	Service      config.Service
+	Pool         serverpool.ServerPool
+	LoadBalancer load_balancer.LoadBalancer
+	// Canary splits off a share of the requests of the route to a canary route, nil when disabled.
+	Canary *Canary
+	// Mirror shadows a share of the requests of the route to a mirror route, nil when disabled.
+	Mirror *Mirror
+	// NewBackend builds a backend of the service registered at runtime, pending its first health check.
+	// It is nil when the route does not support adding backends.
+	NewBackend func(route config.Route) (backend.Backend, error)
+
+	// mux serializes the backends registered at runtime, so two of them never share a URL
+	mux sync.Mutex
+}
+
+// RegisterBackend adds a backend to the pool of the route unless one with the same URL is already registered,
+// it reports whether the backend was added.
+func (r *Route) RegisterBackend(b backend.Backend) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, registered := range r.Pool.ListServiceBackends() {
+		if registered.GetURL().String() == b.GetURL().String() {
+			return false
+		}
+	}
+	r.Pool.RegisterServiceBackend(b)
+	return true
+}
+
+// Router dispatches incoming requests to the load balancer of the service they match.
+// Rules are evaluated first in the order they were added, then the services path prefix
+// and host, and the requests matching nothing go to the default route.
+type Router struct {
+	rules []*rule
+	// routes are sorted from the most to the least specific match
+	routes       []*Route
+	defaultRoute *Route
+}
+
+// NewRouter initializes and returns a new Router without any route.
+func NewRouter() *Router {
+	return &Router{
+		rules:  make([]*rule, 0),
+		routes: make([]*Route, 0),
+	}
+}
+
+// AddRule appends a rule routing the requests it matches to the route.
+func (rt *Router) AddRule(ruleConfig config.Rule, route *Route) error {
+	r, err := newRule(ruleConfig, route)
+	if err != nil {
+		return err
+	}
+	rt.rules = append(rt.rules, r)
+	return nil
+}
+
+// AddRoute registers the route of a service. When several services match a request, the one
+// with the longest path prefix wins, and a service with a host wins over one without.
+func (rt *Router) AddRoute(route *Route) {
+	rt.routes = append(rt.routes, route)
+	sort.SliceStable(rt.routes, func(i, j int) bool {
+		first, second := rt.routes[i].Service, rt.routes[j].Service
+		firstPrefix, secondPrefix := strings.TrimSuffix(first.PathPrefix, "/"), strings.TrimSuffix(second.PathPrefix, "/")
+		if len(firstPrefix) != len(secondPrefix) {
+			return len(firstPrefix) > len(secondPrefix)
+		}
+		return first.Host != "" && second.Host == ""
+	})
+}
+
+// SetDefaultRoute sets the route serving the requests that match no service.
+func (rt *Router) SetDefaultRoute(route *Route) {
+	rt.defaultRoute = route
+}
+
+// Routes returns every route of the router, the default route last when set.
+func (rt *Router) Routes() []*Route {
+	routes := make([]*Route, 0, len(rt.routes)+1)
+	routes = append(routes, rt.routes...)
+	if rt.defaultRoute != nil {
+		routes = append(routes, rt.defaultRoute)
+	}
+	return routes
+}
+
+// Route returns the route of the service with the name, or nil when there is none.
+func (rt *Router) Route(name string) *Route {
+	for _, route := range rt.Routes() {
+		if route.Service.Name == name {
+			return route
+		}
+	}
+	return nil
+}
+
+// Match returns the route of the service matching the request, or nil when none does.
+func (rt *Router) Match(r *http.Request) *Route {
+	for _, rule := range rt.rules {
+		if rule.matches(r) {
+			return rule.route
+		}
+	}
+	for _, route := range rt.routes {
+		if matchHost(route.Service.Host, r.Host) && matchPathPrefix(route.Service.PathPrefix, r.URL.Path) {
+			return route
+		}
+	}
+	return rt.defaultRoute
+}
+
+// Serve handles incoming HTTP requests by forwarding them to the load balancer of the matching service.
+func (rt *Router) Serve(w http.ResponseWriter, r *http.Request) {
+	route := rt.Match(r)
+	if route == nil {
+		// If no service is configured for the request, respond with a 404 Not Found error.
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	if route.Mirror != nil {
+		route.Mirror.Send(r)
+	}
+	if route.Canary != nil && route.Canary.Selects(r) {
+		route = route.Canary.Route()
+	}
+	route.LoadBalancer.Serve(w, r)
+}
+
+// matchHost reports whether the request host, ignoring its port, is the service host.
+func matchHost(serviceHost string, requestHost string) bool {
+	if serviceHost == "" {
+		return true
+	}
+	if host, _, err := net.SplitHostPort(requestHost); err == nil {
+		requestHost = host
+	}
+	return strings.EqualFold(serviceHost, requestHost)
+}
+
+// matchPathPrefix reports whether the path is the prefix or below it, matching whole path segments
+// so that "/api" matches "/api" and "/api/players" but not "/apix".
+func matchPathPrefix(prefix string, path string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
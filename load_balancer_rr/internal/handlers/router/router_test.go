@@ -0,0 +1,101 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+// MockLoadBalancer is a mock implementation of the load_balancer.LoadBalancer interface
+// answering with the name of its service.
+type MockLoadBalancer struct {
+	name string
+}
+
+func (m *MockLoadBalancer) Serve(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(m.name))
+}
+
+func newRoute(name string, pathPrefix string, host string) *Route {
+	return &Route{
+		Service:      config.Service{Name: name, PathPrefix: pathPrefix, Host: host},
+		LoadBalancer: &MockLoadBalancer{name: name},
+	}
+}
+
+func serve(rt *Router, host string, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Host = host
+	rr := httptest.NewRecorder()
+	rt.Serve(rr, req)
+	return rr
+}
+
+func TestRouter_Serve(t *testing.T) {
+	rt := NewRouter()
+	rt.AddRoute(newRoute("game", "/", ""))
+	rt.AddRoute(newRoute("payments", "/payments", ""))
+	rt.AddRoute(newRoute("payments-refunds", "/payments/refunds/", ""))
+	rt.AddRoute(newRoute("auth", "", "auth.example.com"))
+	rt.AddRoute(newRoute("auth-admin", "/admin", "auth.example.com"))
+
+	tests := []struct {
+		name     string
+		host     string
+		path     string
+		expected string
+	}{
+		{name: "catch all prefix", host: "lb.example.com", path: "/create", expected: "game"},
+		{name: "exact prefix", host: "lb.example.com", path: "/payments", expected: "payments"},
+		{name: "below prefix", host: "lb.example.com", path: "/payments/charge", expected: "payments"},
+		{name: "longest prefix wins", host: "lb.example.com", path: "/payments/refunds/42", expected: "payments-refunds"},
+		{name: "prefix matches whole segments", host: "lb.example.com", path: "/paymentsx", expected: "game"},
+		{name: "host match", host: "auth.example.com", path: "/login", expected: "auth"},
+		{name: "host match ignores port and case", host: "AUTH.example.com:8082", path: "/login", expected: "auth"},
+		{name: "host and prefix match", host: "auth.example.com", path: "/admin/users", expected: "auth-admin"},
+		{name: "longer prefix wins over host", host: "auth.example.com", path: "/payments", expected: "payments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(rt, tt.host, tt.path)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestRouter_DefaultRoute(t *testing.T) {
+	rt := NewRouter()
+	rt.AddRoute(newRoute("payments", "/payments", ""))
+
+	// Without a default route unmatched requests are rejected
+	rr := serve(rt, "lb.example.com", "/create")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rt.SetDefaultRoute(newRoute("default", "", ""))
+	rr = serve(rt, "lb.example.com", "/create")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "default", rr.Body.String())
+
+	rr = serve(rt, "lb.example.com", "/payments/charge")
+	assert.Equal(t, "payments", rr.Body.String())
+}
+
+func TestRouter_Routes(t *testing.T) {
+	rt := NewRouter()
+	assert.Empty(t, rt.Routes())
+
+	payments := newRoute("payments", "/payments", "")
+	game := newRoute("game", "/", "")
+	defaultRoute := newRoute("default", "", "")
+	rt.AddRoute(game)
+	rt.AddRoute(payments)
+	rt.SetDefaultRoute(defaultRoute)
+
+	assert.Equal(t, []*Route{payments, game, defaultRoute}, rt.Routes())
+}
@@ -0,0 +1,129 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+// rule is a compiled routing rule sending the requests it matches to a route.
+type rule struct {
+	route      *Route
+	methods    map[string]bool
+	pathPrefix string
+	headers    []valueMatcher
+	query      []valueMatcher
+}
+
+// valueMatcher checks the value of a named header or query parameter.
+type valueMatcher struct {
+	name  string
+	match func(value string) bool
+}
+
+// newRule compiles the rule config for the route.
+func newRule(ruleConfig config.Rule, route *Route) (*rule, error) {
+	r := &rule{
+		route:      route,
+		methods:    make(map[string]bool),
+		pathPrefix: ruleConfig.PathPrefix,
+	}
+	for _, method := range ruleConfig.Methods {
+		r.methods[strings.ToUpper(method)] = true
+	}
+
+	var err error
+	if r.headers, err = newValueMatchers(ruleConfig.Headers); err != nil {
+		return nil, fmt.Errorf("invalid header match: %w", err)
+	}
+	if r.query, err = newValueMatchers(ruleConfig.Query); err != nil {
+		return nil, fmt.Errorf("invalid query match: %w", err)
+	}
+	return r, nil
+}
+
+// matches reports whether the request satisfies every condition of the rule.
+func (r *rule) matches(req *http.Request) bool {
+	if len(r.methods) > 0 && !r.methods[req.Method] {
+		return false
+	}
+	if !matchPathPrefix(r.pathPrefix, req.URL.Path) {
+		return false
+	}
+	for _, header := range r.headers {
+		values, ok := req.Header[http.CanonicalHeaderKey(header.name)]
+		if !ok || !matchAny(header, values) {
+			return false
+		}
+	}
+
+	query := req.URL.Query()
+	for _, param := range r.query {
+		values, ok := query[param.name]
+		if !ok || !matchAny(param, values) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny reports whether one of the values satisfies the matcher.
+func matchAny(matcher valueMatcher, values []string) bool {
+	for _, value := range values {
+		if matcher.match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// newValueMatchers compiles the match configs.
+func newValueMatchers(matches []config.Match) ([]valueMatcher, error) {
+	matchers := make([]valueMatcher, 0, len(matches))
+	for _, match := range matches {
+		matcher, err := newValueMatcher(match)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// newValueMatcher compiles a match config into an exact, prefix, regex or presence matcher.
+func newValueMatcher(match config.Match) (valueMatcher, error) {
+	if match.Name == "" {
+		return valueMatcher{}, errors.New("name is required")
+	}
+
+	conditions := 0
+	for _, condition := range []string{match.Exact, match.Prefix, match.Regex} {
+		if condition != "" {
+			conditions++
+		}
+	}
+	if conditions > 1 {
+		return valueMatcher{}, fmt.Errorf("only one of exact, prefix and regex can be set for %q", match.Name)
+	}
+
+	matcher := valueMatcher{name: match.Name}
+	switch {
+	case match.Exact != "":
+		matcher.match = func(value string) bool { return value == match.Exact }
+	case match.Prefix != "":
+		matcher.match = func(value string) bool { return strings.HasPrefix(value, match.Prefix) }
+	case match.Regex != "":
+		re, err := regexp.Compile(match.Regex)
+		if err != nil {
+			return valueMatcher{}, fmt.Errorf("invalid regex for %q: %w", match.Name, err)
+		}
+		matcher.match = re.MatchString
+	default:
+		matcher.match = func(string) bool { return true }
+	}
+	return matcher, nil
+}
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+)
+
+func TestRouter_Rules(t *testing.T) {
+	rt := NewRouter()
+	main := newRoute("game", "/", "")
+	beta := newRoute("game-beta", "", "")
+	reads := newRoute("game-reads", "", "")
+	mobile := newRoute("game-mobile", "", "")
+	rt.AddRoute(main)
+
+	assert.NoError(t, rt.AddRule(config.Rule{
+		Service: "game-beta",
+		Headers: []config.Match{{Name: "X-Client", Exact: "beta"}},
+	}, beta))
+	assert.NoError(t, rt.AddRule(config.Rule{
+		Service:    "game-reads",
+		Methods:    []string{"get", "HEAD"},
+		PathPrefix: "/players",
+	}, reads))
+	assert.NoError(t, rt.AddRule(config.Rule{
+		Service: "game-mobile",
+		Headers: []config.Match{{Name: "User-Agent", Regex: `(?i)android|iphone`}},
+		Query:   []config.Match{{Name: "platform", Prefix: "mobile"}},
+	}, mobile))
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		headers  map[string]string
+		expected string
+	}{
+		{name: "header exact match", method: http.MethodPost, target: "/create", headers: map[string]string{"X-Client": "beta"}, expected: "game-beta"},
+		{name: "header exact mismatch", method: http.MethodPost, target: "/create", headers: map[string]string{"X-Client": "beta-2"}, expected: "game"},
+		{name: "method and prefix match", method: http.MethodGet, target: "/players/42", expected: "game-reads"},
+		{name: "method mismatch", method: http.MethodPost, target: "/players/42", expected: "game"},
+		{name: "rules in order", method: http.MethodGet, target: "/players/42", headers: map[string]string{"X-Client": "beta"}, expected: "game-beta"},
+		{name: "header regex and query prefix match", method: http.MethodPost, target: "/create?platform=mobile-ios", headers: map[string]string{"User-Agent": "Game/1.0 (iPhone)"}, expected: "game-mobile"},
+		{name: "missing query parameter", method: http.MethodPost, target: "/create", headers: map[string]string{"User-Agent": "Game/1.0 (iPhone)"}, expected: "game"},
+		{name: "no rule matches", method: http.MethodPost, target: "/create", expected: "game"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rr := httptest.NewRecorder()
+			rt.Serve(rr, req)
+			assert.Equal(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestRouter_RulePresenceMatch(t *testing.T) {
+	rt := NewRouter()
+	rt.SetDefaultRoute(newRoute("default", "", ""))
+	assert.NoError(t, rt.AddRule(config.Rule{Headers: []config.Match{{Name: "X-Debug"}}}, newRoute("debug", "", "")))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Debug", "")
+	assert.Equal(t, "debug", rt.Match(req).Service.Name)
+
+	assert.Equal(t, "default", rt.Match(httptest.NewRequest(http.MethodGet, "/", nil)).Service.Name)
+}
+
+func TestRouter_AddRuleInvalid(t *testing.T) {
+	rt := NewRouter()
+	route := newRoute("game", "", "")
+
+	assert.Error(t, rt.AddRule(config.Rule{Headers: []config.Match{{Exact: "beta"}}}, route))
+	assert.Error(t, rt.AddRule(config.Rule{Headers: []config.Match{{Name: "X-Client", Exact: "beta", Prefix: "b"}}}, route))
+	assert.Error(t, rt.AddRule(config.Rule{Query: []config.Match{{Name: "platform", Regex: "("}}}, route))
+	assert.Empty(t, rt.rules)
+}
@@ -0,0 +1,208 @@
+package consistent_hash
+
+import (
+	"hash/fnv"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/requestkey"
+)
+
+// VirtualNodes is the number of points each unit of backend weight owns on the ring,
+// more points give a more even share of keys between backends.
+const VirtualNodes = 160
+
+// DefaultLoadFactor is the bounded load factor used when none is configured.
+const DefaultLoadFactor = 1.25
+
+// ringNode is a single point on the hash ring owned by a backend.
+type ringNode struct {
+	hash    uint64
+	backend backend.Backend
+}
+
+// ConsistentHash represents a load balancer that maps request keys onto a hash ring of backends,
+// so that requests with the same key land on the same backend and adding or removing a backend
+// only moves the keys that it owns.
+type ConsistentHash struct {
+	Backends []backend.Backend
+	ring     []ringNode
+	keyFunc  requestkey.Func
+	// loadFactor bounds the in-flight requests of a backend relative to the pool average, 0 disables the bound
+	loadFactor float64
+	// Current spreads requests without a routing context around the ring
+	Current atomic.Uint64
+	mux     sync.RWMutex // Mutex for synchronizing access
+}
+
+// Initialize initializes and returns a new ConsistentHash instance routing on the given request key.
+func Initialize(keyFunc requestkey.Func) *ConsistentHash {
+	var ch ConsistentHash
+	ch.Backends = make([]backend.Backend, 0)
+	ch.keyFunc = keyFunc
+	return &ch
+}
+
+// InitializeBounded initializes and returns a new ConsistentHash instance with bounded loads:
+// a backend is skipped while its in-flight requests exceed loadFactor times the pool average,
+// and the request moves on to the next backend on the ring.
+func InitializeBounded(keyFunc requestkey.Func, loadFactor float64) *ConsistentHash {
+	if loadFactor <= 1 {
+		loadFactor = DefaultLoadFactor
+	}
+	ch := Initialize(keyFunc)
+	ch.loadFactor = loadFactor
+	return ch
+}
+
+// NextAvailableBackendForRequest returns the alive backend owning the request key,
+// requests without the key are hashed on the client IP.
+func (ch *ConsistentHash) NextAvailableBackendForRequest(r *http.Request) backend.Backend {
+	key := ch.keyFunc(r)
+	if key == "" {
+		key = requestkey.ClientIP(r)
+	}
+	return ch.lookup(hashKey(key))
+}
+
+// NextAvailableBackend returns an alive backend from a rotating point on the ring,
+// it is used when no request is available to derive a key from.
+func (ch *ConsistentHash) NextAvailableBackend() backend.Backend {
+	return ch.lookup(hashKey(strconv.FormatUint(ch.Current.Add(1), 10)))
+}
+
+// lookup walks the ring clockwise from the hash and returns the first alive backend with spare capacity.
+func (ch *ConsistentHash) lookup(hash uint64) backend.Backend {
+	ch.mux.RLock()
+	defer ch.mux.RUnlock()
+
+	capacity := ch.capacity()
+	var firstAlive backend.Backend
+	size := len(ch.ring)
+	start := sort.Search(size, func(i int) bool { return ch.ring[i].hash >= hash })
+	for i := 0; i < size; i++ {
+		node := ch.ring[(start+i)%size]
+		if !node.backend.IsAvailable() {
+			continue
+		}
+		if node.backend.GetActiveConnections() < capacity {
+			return node.backend
+		}
+		if firstAlive == nil {
+			firstAlive = node.backend
+		}
+	}
+	// Every alive backend is at capacity, keep the affinity rather than failing the request
+	return firstAlive
+}
+
+// capacity returns the maximum in-flight requests allowed per backend, the caller must hold the read lock.
+func (ch *ConsistentHash) capacity() int64 {
+	if ch.loadFactor == 0 {
+		return math.MaxInt64
+	}
+
+	var alive, inFlight int64
+	for _, b := range ch.Backends {
+		if b.IsAvailable() {
+			alive++
+			inFlight += b.GetActiveConnections()
+		}
+	}
+	if alive == 0 {
+		return 0
+	}
+	// Count the incoming request so an idle pool still has room for it
+	return int64(math.Ceil(ch.loadFactor * float64(inFlight+1) / float64(alive)))
+}
+
+// rebuildRing places the virtual nodes of every backend on the ring, the caller must hold the write lock.
+func (ch *ConsistentHash) rebuildRing() {
+	ring := make([]ringNode, 0, len(ch.Backends)*VirtualNodes)
+	for _, b := range ch.Backends {
+		address := b.GetURL().String()
+		for i := 0; i < VirtualNodes*b.GetWeight(); i++ {
+			ring = append(ring, ringNode{
+				hash:    hashKey(address + "#" + strconv.Itoa(i)),
+				backend: b,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+	ch.ring = ring
+}
+
+// hashKey hashes a key onto the ring. FNV-1a is finalised with a bit mixer because
+// the virtual node labels only differ in their last characters.
+func hashKey(key string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(key))
+	hash := hasher.Sum64()
+
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
+
+// ListServiceBackends returns all Backends in the pool.
+func (ch *ConsistentHash) ListServiceBackends() []backend.Backend {
+	ch.mux.RLock()
+	defer ch.mux.RUnlock()
+
+	backendsCopy := make([]backend.Backend, len(ch.Backends))
+	copy(backendsCopy, ch.Backends)
+	return backendsCopy
+}
+
+// GetServerPoolSize returns the number of Backends in the pool.
+func (ch *ConsistentHash) GetServerPoolSize() int32 {
+	ch.mux.RLock()
+	defer ch.mux.RUnlock()
+
+	return int32(len(ch.Backends))
+}
+
+// CountAvailableBackends returns the number of Backends in the pool able to take new requests.
+func (ch *ConsistentHash) CountAvailableBackends() int {
+	ch.mux.RLock()
+	defer ch.mux.RUnlock()
+
+	count := 0
+	for _, b := range ch.Backends {
+		if b.IsAvailable() {
+			count++
+		}
+	}
+	return count
+}
+
+// RegisterServiceBackend adds a new backend to the pool and places it on the ring.
+func (ch *ConsistentHash) RegisterServiceBackend(backend backend.Backend) {
+	ch.mux.Lock()
+	defer ch.mux.Unlock()
+
+	ch.Backends = append(ch.Backends, backend)
+	ch.rebuildRing()
+}
+
+// RemoveBackend removes a backend from the pool and from the ring.
+func (ch *ConsistentHash) RemoveBackend(backend backend.Backend) {
+	ch.mux.Lock()
+	defer ch.mux.Unlock()
+
+	for i, b := range ch.Backends {
+		if b == backend {
+			ch.Backends = append(ch.Backends[:i], ch.Backends[i+1:]...)
+			ch.rebuildRing()
+			break
+		}
+	}
+}
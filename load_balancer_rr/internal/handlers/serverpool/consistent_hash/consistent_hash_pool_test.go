@@ -0,0 +1,205 @@
+package consistent_hash
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newBackend(t *testing.T, rawURL string) backend.Backend {
+	parsedURL, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	return backend.NewBackendServer(parsedURL, nil)
+}
+
+func gamerKey(r *http.Request) string {
+	return r.Header.Get("X-Gamer-Id")
+}
+
+func newGamerRequest(gamerID string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/create", nil)
+	req.Header.Set("X-Gamer-Id", gamerID)
+	return req
+}
+
+func TestInitialize(t *testing.T) {
+	ch := Initialize(gamerKey)
+	assert.NotNil(t, ch)
+	assert.Empty(t, ch.Backends)
+	assert.Nil(t, ch.NextAvailableBackend())
+	assert.Nil(t, ch.NextAvailableBackendForRequest(newGamerRequest("GYUTDTE")))
+}
+
+func TestNextAvailableBackendForRequest_SameKeySameBackend(t *testing.T) {
+	ch := Initialize(gamerKey)
+	for port := 8085; port <= 8087; port++ {
+		ch.RegisterServiceBackend(newBackend(t, fmt.Sprintf("http://localhost:%d", port)))
+	}
+
+	first := ch.NextAvailableBackendForRequest(newGamerRequest("GYUTDTE"))
+	assert.NotNil(t, first)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, ch.NextAvailableBackendForRequest(newGamerRequest("GYUTDTE")))
+	}
+}
+
+func TestNextAvailableBackendForRequest_SpreadsKeys(t *testing.T) {
+	ch := Initialize(gamerKey)
+	for port := 8085; port <= 8087; port++ {
+		ch.RegisterServiceBackend(newBackend(t, fmt.Sprintf("http://localhost:%d", port)))
+	}
+
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 3000; i++ {
+		counts[ch.NextAvailableBackendForRequest(newGamerRequest(fmt.Sprintf("gamer-%d", i)))]++
+	}
+	assert.Len(t, counts, 3)
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 250)
+	}
+}
+
+func TestNextAvailableBackendForRequest_MinimalMovement(t *testing.T) {
+	ch := Initialize(gamerKey)
+	backends := make([]backend.Backend, 0)
+	for port := 8085; port <= 8088; port++ {
+		b := newBackend(t, fmt.Sprintf("http://localhost:%d", port))
+		backends = append(backends, b)
+		ch.RegisterServiceBackend(b)
+	}
+
+	const keys = 2000
+	before := make([]backend.Backend, keys)
+	for i := range before {
+		before[i] = ch.NextAvailableBackendForRequest(newGamerRequest(fmt.Sprintf("gamer-%d", i)))
+	}
+
+	removed := backends[1]
+	ch.RemoveBackend(removed)
+
+	moved := 0
+	for i := range before {
+		after := ch.NextAvailableBackendForRequest(newGamerRequest(fmt.Sprintf("gamer-%d", i)))
+		if before[i] != removed {
+			// Keys of the remaining backends must stay where they were
+			assert.Equal(t, before[i], after)
+			continue
+		}
+		moved++
+	}
+	// Only the keys of the removed backend move, roughly a quarter of them
+	assert.InDelta(t, keys/4, moved, keys/10)
+}
+
+func TestNextAvailableBackendForRequest_SkipsDeadBackends(t *testing.T) {
+	ch := Initialize(gamerKey)
+	a := newBackend(t, "http://localhost:8085")
+	b := newBackend(t, "http://localhost:8086")
+	ch.RegisterServiceBackend(a)
+	ch.RegisterServiceBackend(b)
+
+	owner := ch.NextAvailableBackendForRequest(newGamerRequest("GYUTDTE"))
+	owner.SetAlive(false)
+
+	fallback := ch.NextAvailableBackendForRequest(newGamerRequest("GYUTDTE"))
+	assert.NotNil(t, fallback)
+	assert.NotEqual(t, owner, fallback)
+
+	fallback.SetAlive(false)
+	assert.Nil(t, ch.NextAvailableBackendForRequest(newGamerRequest("GYUTDTE")))
+}
+
+func TestNextAvailableBackendForRequest_FallsBackToClientIP(t *testing.T) {
+	ch := Initialize(gamerKey)
+	for port := 8085; port <= 8087; port++ {
+		ch.RegisterServiceBackend(newBackend(t, fmt.Sprintf("http://localhost:%d", port)))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/create", nil)
+	req.RemoteAddr = "10.0.0.7:1234"
+	first := ch.NextAvailableBackendForRequest(req)
+
+	req.RemoteAddr = "10.0.0.7:5678"
+	assert.Equal(t, first, ch.NextAvailableBackendForRequest(req))
+}
+
+func TestRegisterAndRemoveBackend(t *testing.T) {
+	ch := Initialize(gamerKey)
+	a := newBackend(t, "http://localhost:8085")
+	b := newBackend(t, "http://localhost:8086")
+	ch.RegisterServiceBackend(a)
+	ch.RegisterServiceBackend(b)
+	assert.Equal(t, int32(2), ch.GetServerPoolSize())
+	assert.Len(t, ch.ring, 2*VirtualNodes)
+
+	ch.RemoveBackend(a)
+	assert.Equal(t, int32(1), ch.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{b}, ch.ListServiceBackends())
+	assert.Len(t, ch.ring, VirtualNodes)
+}
+
+// loadedBackend overrides the in-flight requests of a backend.
+type loadedBackend struct {
+	backend.Backend
+	active atomic.Int64
+}
+
+// GetActiveConnections returns the mocked number of in-flight requests.
+func (l *loadedBackend) GetActiveConnections() int64 {
+	return l.active.Load()
+}
+
+func TestInitializeBounded(t *testing.T) {
+	assert.Equal(t, DefaultLoadFactor, InitializeBounded(gamerKey, 0).loadFactor)
+	assert.Equal(t, 2.0, InitializeBounded(gamerKey, 2).loadFactor)
+	assert.Equal(t, 0.0, Initialize(gamerKey).loadFactor)
+}
+
+func TestNextAvailableBackendForRequest_BoundedLoad(t *testing.T) {
+	ch := InitializeBounded(gamerKey, 1.25)
+	backends := make([]*loadedBackend, 0)
+	for port := 8085; port <= 8087; port++ {
+		b := &loadedBackend{Backend: newBackend(t, fmt.Sprintf("http://localhost:%d", port))}
+		backends = append(backends, b)
+		ch.RegisterServiceBackend(b)
+	}
+
+	req := newGamerRequest("GYUTDTE")
+	owner := ch.NextAvailableBackendForRequest(req).(*loadedBackend)
+
+	// A hot key overloads its owner: 10 in-flight against a capacity of ceil(1.25 * 11 / 3) = 5
+	owner.active.Store(10)
+	spill := ch.NextAvailableBackendForRequest(req)
+	assert.NotEqual(t, owner, spill)
+	// The spill over target is stable, so the key keeps a secondary affinity
+	assert.Equal(t, spill, ch.NextAvailableBackendForRequest(req))
+
+	// Once the load drops the key returns to its owner
+	owner.active.Store(0)
+	assert.Equal(t, owner, ch.NextAvailableBackendForRequest(req))
+
+	// The unbounded variant keeps the affinity regardless of the load
+	unbounded := Initialize(gamerKey)
+	for _, b := range backends {
+		unbounded.RegisterServiceBackend(b)
+	}
+	owner.active.Store(10)
+	assert.Equal(t, owner, unbounded.NextAvailableBackendForRequest(req))
+}
+
+func TestNextAvailableBackendForRequest_BoundedLoadAllAtCapacity(t *testing.T) {
+	ch := InitializeBounded(gamerKey, 1.25)
+	b := &loadedBackend{Backend: newBackend(t, "http://localhost:8085")}
+	ch.RegisterServiceBackend(b)
+
+	// A single backend is always within capacity of its own average
+	b.active.Store(100)
+	assert.Equal(t, b, ch.NextAvailableBackendForRequest(newGamerRequest("GYUTDTE")))
+}
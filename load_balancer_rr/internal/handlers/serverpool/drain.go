@@ -0,0 +1,50 @@
+package serverpool
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+const (
+	// DefaultDrainTimeout bounds the draining of a backend when its service sets no drain timeout.
+	DefaultDrainTimeout = 30 * time.Second
+	// DrainPollInterval is how often the in-flight requests of a draining backend are checked.
+	DrainPollInterval = 100 * time.Millisecond
+)
+
+// Drain removes the backend from the pool once it has finished its in-flight requests, or once the timeout
+// runs out. The backend must already be draining, so that it gets no new requests meanwhile, and it is kept
+// in the pool when it is undrained before its removal. It blocks until the backend is removed or undrained,
+// and reports whether it was drained and removed before the timeout.
+func Drain(pool ServerPool, b backend.Backend, timeout time.Duration) bool {
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(DrainPollInterval)
+	defer ticker.Stop()
+
+	drained := b.GetActiveConnections() == 0
+	for !drained && time.Now().Before(deadline) {
+		<-ticker.C
+		if !b.IsDraining() {
+			config.Logger.Info("kept undrained server", zap.String("host", b.GetURL().Host))
+			return false
+		}
+		drained = b.GetActiveConnections() == 0
+	}
+
+	pool.RemoveBackend(b)
+	if !drained {
+		// Push alert here: backend removed with requests still in flight
+		config.Logger.Warn("removed draining server before its requests finished", zap.String("host", b.GetURL().Host),
+			zap.Int64("inFlight", b.GetActiveConnections()))
+		return false
+	}
+	config.Logger.Info("removed drained server", zap.String("host", b.GetURL().Host))
+	return true
+}
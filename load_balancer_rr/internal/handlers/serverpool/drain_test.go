@@ -0,0 +1,90 @@
+package serverpool
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+)
+
+// newHangingBackend returns a backend with one request in flight, held until release is closed.
+func newHangingBackend(t *testing.T, release chan struct{}) backend.Backend {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	b := backend.NewBackendServer(u, httputil.NewSingleHostReverseProxy(u))
+	go b.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	<-started
+	return b
+}
+
+func TestDrain(t *testing.T) {
+	release := make(chan struct{})
+	b := newHangingBackend(t, release)
+	pool := round_robin.Initialize()
+	pool.RegisterServiceBackend(b)
+
+	assert.True(t, b.Drain())
+	assert.False(t, b.Drain())
+	assert.Nil(t, pool.NextAvailableBackend())
+
+	drained := make(chan bool)
+	go func() { drained <- Drain(pool, b, time.Minute) }()
+
+	select {
+	case <-drained:
+		t.Fatal("Expected the backend to stay in the pool while its request is in flight")
+	case <-time.After(3 * DrainPollInterval):
+	}
+	assert.Equal(t, int32(1), pool.GetServerPoolSize())
+
+	close(release)
+	assert.True(t, <-drained)
+	assert.Equal(t, int32(0), pool.GetServerPoolSize())
+}
+
+func TestDrain_Undrained(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	b := newHangingBackend(t, release)
+	pool := round_robin.Initialize()
+	pool.RegisterServiceBackend(b)
+	b.Drain()
+
+	drained := make(chan bool)
+	go func() { drained <- Drain(pool, b, time.Minute) }()
+
+	// Undraining the backend before its requests finish keeps it in the pool
+	time.Sleep(2 * DrainPollInterval)
+	assert.True(t, b.Undrain())
+	assert.False(t, <-drained)
+	assert.Equal(t, int32(1), pool.GetServerPoolSize())
+	assert.Equal(t, b, pool.NextAvailableBackend())
+}
+
+func TestDrain_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	b := newHangingBackend(t, release)
+	pool := round_robin.Initialize()
+	pool.RegisterServiceBackend(b)
+	b.Drain()
+
+	started := time.Now()
+	assert.False(t, Drain(pool, b, 2*DrainPollInterval))
+	assert.Less(t, time.Since(started), time.Second)
+	assert.Equal(t, int32(0), pool.GetServerPoolSize())
+	assert.Equal(t, int64(1), b.GetActiveConnections())
+}
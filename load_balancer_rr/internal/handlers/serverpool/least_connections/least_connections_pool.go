@@ -0,0 +1,104 @@
+package least_connections
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// LeastConnections represents a load balancer that sends each request to the backend
+// with the fewest in-flight requests.
+type LeastConnections struct {
+	Backends []backend.Backend
+	// Offset rotates the scan start so that ties are spread across backends
+	Offset atomic.Uint32
+	mux    sync.RWMutex // Mutex for synchronizing access
+}
+
+// Initialize initializes and returns a new LeastConnections instance.
+func Initialize() *LeastConnections {
+	var lc LeastConnections
+	lc.Backends = make([]backend.Backend, 0)
+	return &lc
+}
+
+// NextAvailableBackend returns the alive backend with the fewest active connections.
+func (lc *LeastConnections) NextAvailableBackend() backend.Backend {
+	lc.mux.RLock()
+	defer lc.mux.RUnlock()
+
+	size := len(lc.Backends)
+	if size == 0 {
+		return nil
+	}
+
+	var selected backend.Backend
+	var fewest int64
+	start := int(lc.Offset.Add(1) % uint32(size))
+	for i := 0; i < size; i++ {
+		candidate := lc.Backends[(start+i)%size]
+		if !candidate.IsAvailable() {
+			continue
+		}
+		active := candidate.GetActiveConnections()
+		if selected == nil || active < fewest {
+			selected = candidate
+			fewest = active
+		}
+	}
+	return selected
+}
+
+// ListServiceBackends returns all Backends in the pool.
+func (lc *LeastConnections) ListServiceBackends() []backend.Backend {
+	lc.mux.RLock()
+	defer lc.mux.RUnlock()
+
+	backendsCopy := make([]backend.Backend, len(lc.Backends))
+	copy(backendsCopy, lc.Backends)
+	return backendsCopy
+}
+
+// GetServerPoolSize returns the number of Backends in the pool.
+func (lc *LeastConnections) GetServerPoolSize() int32 {
+	lc.mux.RLock()
+	defer lc.mux.RUnlock()
+
+	return int32(len(lc.Backends))
+}
+
+// CountAvailableBackends returns the number of Backends in the pool able to take new requests.
+func (lc *LeastConnections) CountAvailableBackends() int {
+	lc.mux.RLock()
+	defer lc.mux.RUnlock()
+
+	count := 0
+	for _, b := range lc.Backends {
+		if b.IsAvailable() {
+			count++
+		}
+	}
+	return count
+}
+
+// RegisterServiceBackend adds a new backend to the pool.
+func (lc *LeastConnections) RegisterServiceBackend(backend backend.Backend) {
+	lc.mux.Lock()
+	defer lc.mux.Unlock()
+
+	lc.Backends = append(lc.Backends, backend)
+}
+
+// RemoveBackend removes a backend from the pool.
+func (lc *LeastConnections) RemoveBackend(backend backend.Backend) {
+	lc.mux.Lock()
+	defer lc.mux.Unlock()
+
+	for i, b := range lc.Backends {
+		if b == backend {
+			lc.Backends = append(lc.Backends[:i], lc.Backends[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package least_connections
+
+import (
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// MockBackend is a mock implementation of the backend.Backend interface
+type MockBackend struct {
+	// Backend satisfies the methods the least connections pool does not rely on
+	backend.Backend
+	alive  atomic.Bool
+	active atomic.Int64
+	name   string
+}
+
+func newMockBackend(name string, active int64) *MockBackend {
+	m := &MockBackend{name: name}
+	m.alive.Store(true)
+	m.active.Store(active)
+	return m
+}
+
+// IsAlive returns the alive status of the backend.
+func (m *MockBackend) IsAlive() bool {
+	return m.alive.Load()
+}
+
+// IsAvailable reports the alive status of the backend, the mock is never ejected.
+func (m *MockBackend) IsAvailable() bool {
+	return m.alive.Load()
+}
+
+// GetActiveConnections returns the mocked number of in-flight requests.
+func (m *MockBackend) GetActiveConnections() int64 {
+	return m.active.Load()
+}
+
+// GetURL returns the URL of the backend.
+func (m *MockBackend) GetURL() *url.URL {
+	return &url.URL{Scheme: "http", Host: m.name}
+}
+
+func TestInitialize(t *testing.T) {
+	lc := Initialize()
+	assert.NotNil(t, lc)
+	assert.Empty(t, lc.Backends)
+	assert.Nil(t, lc.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_PicksFewestConnections(t *testing.T) {
+	lc := Initialize()
+	busy := newMockBackend("busy", 10)
+	idle := newMockBackend("idle", 1)
+	stalled := newMockBackend("stalled", 50)
+	lc.RegisterServiceBackend(busy)
+	lc.RegisterServiceBackend(idle)
+	lc.RegisterServiceBackend(stalled)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, idle, lc.NextAvailableBackend())
+	}
+
+	idle.active.Store(20)
+	assert.Equal(t, busy, lc.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_SkipsDeadBackends(t *testing.T) {
+	lc := Initialize()
+	idle := newMockBackend("idle", 0)
+	busy := newMockBackend("busy", 5)
+	lc.RegisterServiceBackend(idle)
+	lc.RegisterServiceBackend(busy)
+
+	idle.alive.Store(false)
+	assert.Equal(t, busy, lc.NextAvailableBackend())
+
+	busy.alive.Store(false)
+	assert.Nil(t, lc.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_SpreadsTies(t *testing.T) {
+	lc := Initialize()
+	a := newMockBackend("a", 0)
+	b := newMockBackend("b", 0)
+	lc.RegisterServiceBackend(a)
+	lc.RegisterServiceBackend(b)
+
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 10; i++ {
+		counts[lc.NextAvailableBackend()]++
+	}
+	assert.Equal(t, 5, counts[a])
+	assert.Equal(t, 5, counts[b])
+}
+
+func TestRegisterAndRemoveBackend(t *testing.T) {
+	lc := Initialize()
+	a := newMockBackend("a", 0)
+	b := newMockBackend("b", 0)
+	lc.RegisterServiceBackend(a)
+	lc.RegisterServiceBackend(b)
+	assert.Equal(t, int32(2), lc.GetServerPoolSize())
+
+	lc.RemoveBackend(a)
+	assert.Equal(t, int32(1), lc.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{b}, lc.ListServiceBackends())
+}
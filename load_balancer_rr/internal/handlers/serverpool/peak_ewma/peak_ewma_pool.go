@@ -0,0 +1,113 @@
+package peak_ewma
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// PeakEWMA represents a latency aware load balancer using the power of two choices:
+// it samples two random alive backends and sends the request to the one with the lower cost,
+// where the cost is the peak moving average of the response time scaled by the in-flight requests.
+type PeakEWMA struct {
+	Backends []backend.Backend
+	mux      sync.RWMutex // Mutex for synchronizing access
+}
+
+// Initialize initializes and returns a new PeakEWMA instance.
+func Initialize() *PeakEWMA {
+	var pe PeakEWMA
+	pe.Backends = make([]backend.Backend, 0)
+	return &pe
+}
+
+// NextAvailableBackend returns the cheaper of two randomly chosen alive backends.
+func (pe *PeakEWMA) NextAvailableBackend() backend.Backend {
+	pe.mux.RLock()
+	alive := make([]backend.Backend, 0, len(pe.Backends))
+	for _, b := range pe.Backends {
+		if b.IsAvailable() {
+			alive = append(alive, b)
+		}
+	}
+	pe.mux.RUnlock()
+
+	switch len(alive) {
+	case 0:
+		return nil
+	case 1:
+		return alive[0]
+	}
+
+	// Pick two distinct backends at random
+	first := rand.IntN(len(alive))
+	second := rand.IntN(len(alive) - 1)
+	if second >= first {
+		second++
+	}
+
+	if cost(alive[second]) < cost(alive[first]) {
+		return alive[second]
+	}
+	return alive[first]
+}
+
+// cost estimates the time a new request would wait on the backend. A backend without
+// observations costs nothing, so new and recovered backends are probed quickly.
+func cost(b backend.Backend) float64 {
+	return float64(b.GetLatencyEWMA()) * float64(b.GetActiveConnections()+1)
+}
+
+// ListServiceBackends returns all Backends in the pool.
+func (pe *PeakEWMA) ListServiceBackends() []backend.Backend {
+	pe.mux.RLock()
+	defer pe.mux.RUnlock()
+
+	backendsCopy := make([]backend.Backend, len(pe.Backends))
+	copy(backendsCopy, pe.Backends)
+	return backendsCopy
+}
+
+// GetServerPoolSize returns the number of Backends in the pool.
+func (pe *PeakEWMA) GetServerPoolSize() int32 {
+	pe.mux.RLock()
+	defer pe.mux.RUnlock()
+
+	return int32(len(pe.Backends))
+}
+
+// CountAvailableBackends returns the number of Backends in the pool able to take new requests.
+func (pe *PeakEWMA) CountAvailableBackends() int {
+	pe.mux.RLock()
+	defer pe.mux.RUnlock()
+
+	count := 0
+	for _, b := range pe.Backends {
+		if b.IsAvailable() {
+			count++
+		}
+	}
+	return count
+}
+
+// RegisterServiceBackend adds a new backend to the pool.
+func (pe *PeakEWMA) RegisterServiceBackend(backend backend.Backend) {
+	pe.mux.Lock()
+	defer pe.mux.Unlock()
+
+	pe.Backends = append(pe.Backends, backend)
+}
+
+// RemoveBackend removes a backend from the pool.
+func (pe *PeakEWMA) RemoveBackend(backend backend.Backend) {
+	pe.mux.Lock()
+	defer pe.mux.Unlock()
+
+	for i, b := range pe.Backends {
+		if b == backend {
+			pe.Backends = append(pe.Backends[:i], pe.Backends[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package peak_ewma
+
+import (
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// MockBackend is a mock implementation of the backend.Backend interface
+type MockBackend struct {
+	// Backend satisfies the methods the peak EWMA pool does not rely on
+	backend.Backend
+	alive   atomic.Bool
+	active  atomic.Int64
+	latency atomic.Int64
+	name    string
+}
+
+func newMockBackend(name string, latency time.Duration, active int64) *MockBackend {
+	m := &MockBackend{name: name}
+	m.alive.Store(true)
+	m.latency.Store(int64(latency))
+	m.active.Store(active)
+	return m
+}
+
+// IsAlive returns the alive status of the backend.
+func (m *MockBackend) IsAlive() bool {
+	return m.alive.Load()
+}
+
+// IsAvailable reports the alive status of the backend, the mock is never ejected.
+func (m *MockBackend) IsAvailable() bool {
+	return m.alive.Load()
+}
+
+// GetActiveConnections returns the mocked number of in-flight requests.
+func (m *MockBackend) GetActiveConnections() int64 {
+	return m.active.Load()
+}
+
+// GetLatencyEWMA returns the mocked latency average.
+func (m *MockBackend) GetLatencyEWMA() time.Duration {
+	return time.Duration(m.latency.Load())
+}
+
+// GetURL returns the URL of the backend.
+func (m *MockBackend) GetURL() *url.URL {
+	return &url.URL{Scheme: "http", Host: m.name}
+}
+
+func TestInitialize(t *testing.T) {
+	pe := Initialize()
+	assert.NotNil(t, pe)
+	assert.Empty(t, pe.Backends)
+	assert.Nil(t, pe.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_PrefersLowerCost(t *testing.T) {
+	pe := Initialize()
+	fast := newMockBackend("fast", 20*time.Millisecond, 2)
+	slow := newMockBackend("slow", 200*time.Millisecond, 0)
+	pe.RegisterServiceBackend(fast)
+	pe.RegisterServiceBackend(slow)
+
+	// With two alive backends both are always sampled, so the cheaper one always wins
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, fast, pe.NextAvailableBackend())
+	}
+
+	// Queued requests make the fast backend more expensive than the idle slow one
+	fast.active.Store(20)
+	assert.Equal(t, slow, pe.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_AvoidsStalledBackend(t *testing.T) {
+	pe := Initialize()
+	stalled := newMockBackend("stalled", 50*time.Second, 1)
+	pe.RegisterServiceBackend(stalled)
+	healthy := []*MockBackend{
+		newMockBackend("healthy-1", 30*time.Millisecond, 1),
+		newMockBackend("healthy-2", 40*time.Millisecond, 1),
+		newMockBackend("healthy-3", 50*time.Millisecond, 1),
+	}
+	for _, b := range healthy {
+		pe.RegisterServiceBackend(b)
+	}
+
+	// The stalled backend can only be picked when it is sampled twice, which never happens
+	for i := 0; i < 100; i++ {
+		assert.NotEqual(t, stalled, pe.NextAvailableBackend())
+	}
+}
+
+func TestNextAvailableBackend_SkipsDeadBackends(t *testing.T) {
+	pe := Initialize()
+	fast := newMockBackend("fast", 10*time.Millisecond, 0)
+	slow := newMockBackend("slow", time.Second, 0)
+	pe.RegisterServiceBackend(fast)
+	pe.RegisterServiceBackend(slow)
+
+	fast.alive.Store(false)
+	assert.Equal(t, slow, pe.NextAvailableBackend())
+
+	slow.alive.Store(false)
+	assert.Nil(t, pe.NextAvailableBackend())
+}
+
+func TestRegisterAndRemoveBackend(t *testing.T) {
+	pe := Initialize()
+	a := newMockBackend("a", 0, 0)
+	b := newMockBackend("b", 0, 0)
+	pe.RegisterServiceBackend(a)
+	pe.RegisterServiceBackend(b)
+	assert.Equal(t, int32(2), pe.GetServerPoolSize())
+
+	pe.RemoveBackend(a)
+	assert.Equal(t, int32(1), pe.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{b}, pe.ListServiceBackends())
+}
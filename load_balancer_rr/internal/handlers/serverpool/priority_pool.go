@@ -0,0 +1,141 @@
+package serverpool
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// priorityTier groups the backends sharing a failover priority in their own pool.
+type priorityTier struct {
+	priority int
+	pool     ServerPool
+}
+
+// PriorityPool splits the backends into failover tiers by priority. Requests go to the
+// lowest priority tier with enough available backends, backup tiers only receive traffic once
+// fewer than minHealthy backends of the tiers before them are available.
+// The tiers are an immutable snapshot swapped on registration, so selection takes no lock.
+type PriorityPool struct {
+	tiers      atomic.Pointer[[]*priorityTier] // sorted by ascending priority
+	newPool    func() ServerPool
+	minHealthy int
+	mux        sync.Mutex // Serializes the writers swapping the tiers
+}
+
+// NewPriorityPool initializes and returns a new PriorityPool, creating the pool of each tier with newPool.
+func NewPriorityPool(newPool func() ServerPool, minHealthy int) *PriorityPool {
+	if minHealthy < 1 {
+		minHealthy = 1
+	}
+	pp := &PriorityPool{
+		newPool:    newPool,
+		minHealthy: minHealthy,
+	}
+	pp.tiers.Store(&[]*priorityTier{})
+	return pp
+}
+
+// NextAvailableBackend retrieves the next valid backend from the active tier.
+func (pp *PriorityPool) NextAvailableBackend() backend.Backend {
+	if tier := pp.activeTier(); tier != nil {
+		return tier.NextAvailableBackend()
+	}
+	return nil
+}
+
+// NextAvailableBackendForRequest retrieves the next valid backend for the request from the active tier.
+func (pp *PriorityPool) NextAvailableBackendForRequest(r *http.Request) backend.Backend {
+	if tier := pp.activeTier(); tier != nil {
+		return SelectBackend(tier, r)
+	}
+	return nil
+}
+
+// ActiveBackends returns the backends the active tier currently selects from.
+func (pp *PriorityPool) ActiveBackends() []backend.Backend {
+	if tier := pp.activeTier(); tier != nil {
+		return ActiveBackends(tier)
+	}
+	return nil
+}
+
+// activeTier returns the pool of the first tier with at least minHealthy available backends.
+// When no tier has enough of them, the first tier with any available backend is used instead.
+func (pp *PriorityPool) activeTier() ServerPool {
+	var degraded ServerPool
+	for _, tier := range *pp.tiers.Load() {
+		available := tier.pool.CountAvailableBackends()
+		if available >= pp.minHealthy {
+			return tier.pool
+		}
+		if available > 0 && degraded == nil {
+			degraded = tier.pool
+		}
+	}
+	return degraded
+}
+
+// ListServiceBackends returns the backends of every tier, primary tier first.
+func (pp *PriorityPool) ListServiceBackends() []backend.Backend {
+	backends := make([]backend.Backend, 0)
+	for _, tier := range *pp.tiers.Load() {
+		backends = append(backends, tier.pool.ListServiceBackends()...)
+	}
+	return backends
+}
+
+// GetServerPoolSize returns the number of backends across every tier.
+func (pp *PriorityPool) GetServerPoolSize() int32 {
+	var size int32
+	for _, tier := range *pp.tiers.Load() {
+		size += tier.pool.GetServerPoolSize()
+	}
+	return size
+}
+
+// CountAvailableBackends returns the number of backends across every tier able to take new requests.
+func (pp *PriorityPool) CountAvailableBackends() int {
+	count := 0
+	for _, tier := range *pp.tiers.Load() {
+		count += tier.pool.CountAvailableBackends()
+	}
+	return count
+}
+
+// RegisterServiceBackend adds a new backend to the tier of its priority, creating the tier when needed.
+func (pp *PriorityPool) RegisterServiceBackend(backend backend.Backend) {
+	pp.mux.Lock()
+	defer pp.mux.Unlock()
+
+	current := *pp.tiers.Load()
+	for _, tier := range current {
+		if tier.priority == backend.GetPriority() {
+			tier.pool.RegisterServiceBackend(backend)
+			return
+		}
+	}
+
+	tier := &priorityTier{priority: backend.GetPriority(), pool: pp.newPool()}
+	tier.pool.RegisterServiceBackend(backend)
+	tiers := make([]*priorityTier, 0, len(current)+1)
+	tiers = append(append(tiers, current...), tier)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].priority < tiers[j].priority })
+	pp.tiers.Store(&tiers)
+}
+
+// RemoveBackend removes the backend from its tier.
+func (pp *PriorityPool) RemoveBackend(backend backend.Backend) {
+	pp.mux.Lock()
+	defer pp.mux.Unlock()
+
+	for _, tier := range *pp.tiers.Load() {
+		if tier.priority == backend.GetPriority() {
+			tier.pool.RemoveBackend(backend)
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package serverpool
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/consistent_hash"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+)
+
+func newTieredBackend(t *testing.T, rawURL string, priority int) backend.Backend {
+	parsedURL, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	return backend.NewBackendServer(parsedURL, nil, backend.WithPriority(priority))
+}
+
+func newRoundRobinTier() ServerPool {
+	return round_robin.Initialize()
+}
+
+func TestPriorityPool_PrimaryTierPreferred(t *testing.T) {
+	pp := NewPriorityPool(newRoundRobinTier, 0)
+	backup := newTieredBackend(t, "http://standby:8085", 1)
+	primaryA := newTieredBackend(t, "http://localhost:8085", 0)
+	primaryB := newTieredBackend(t, "http://localhost:8086", 0)
+	pp.RegisterServiceBackend(backup)
+	pp.RegisterServiceBackend(primaryA)
+	pp.RegisterServiceBackend(primaryB)
+
+	assert.Equal(t, int32(3), pp.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{primaryA, primaryB, backup}, pp.ListServiceBackends())
+
+	for i := 0; i < 6; i++ {
+		assert.NotEqual(t, backup, pp.NextAvailableBackend())
+	}
+}
+
+func TestPriorityPool_FailoverWhenPrimariesDead(t *testing.T) {
+	pp := NewPriorityPool(newRoundRobinTier, 1)
+	primary := newTieredBackend(t, "http://localhost:8085", 0)
+	backup := newTieredBackend(t, "http://standby:8085", 1)
+	pp.RegisterServiceBackend(primary)
+	pp.RegisterServiceBackend(backup)
+
+	primary.SetAlive(false)
+	assert.Equal(t, backup, pp.NextAvailableBackend())
+
+	// Traffic returns to the primary tier once it recovers
+	primary.SetAlive(true)
+	assert.Equal(t, primary, pp.NextAvailableBackend())
+
+	primary.SetAlive(false)
+	backup.SetAlive(false)
+	assert.Nil(t, pp.NextAvailableBackend())
+}
+
+func TestPriorityPool_ActiveBackends(t *testing.T) {
+	pp := NewPriorityPool(newRoundRobinTier, 1)
+	primary := newTieredBackend(t, "http://localhost:8085", 0)
+	backup := newTieredBackend(t, "http://standby:8085", 1)
+	pp.RegisterServiceBackend(primary)
+	pp.RegisterServiceBackend(backup)
+	assert.Equal(t, []backend.Backend{primary}, ActiveBackends(pp))
+
+	primary.SetAlive(false)
+	assert.Equal(t, []backend.Backend{backup}, ActiveBackends(pp))
+
+	primary.SetAlive(true)
+	assert.Equal(t, []backend.Backend{primary}, ActiveBackends(pp))
+
+	primary.SetAlive(false)
+	backup.SetAlive(false)
+	assert.Empty(t, ActiveBackends(pp))
+}
+
+func TestPriorityPool_FailoverBelowMinHealthy(t *testing.T) {
+	pp := NewPriorityPool(newRoundRobinTier, 2)
+	primaryA := newTieredBackend(t, "http://localhost:8085", 0)
+	primaryB := newTieredBackend(t, "http://localhost:8086", 0)
+	backupA := newTieredBackend(t, "http://standby:8085", 1)
+	backupB := newTieredBackend(t, "http://standby:8086", 1)
+	for _, b := range []backend.Backend{primaryA, primaryB, backupA, backupB} {
+		pp.RegisterServiceBackend(b)
+	}
+
+	// One primary left is fewer than the required two, so the backup tier takes over
+	primaryA.SetAlive(false)
+	for i := 0; i < 4; i++ {
+		selected := pp.NextAvailableBackend()
+		assert.Contains(t, []backend.Backend{backupA, backupB}, selected)
+	}
+
+	// When the backup tier is degraded too, the remaining primary keeps serving
+	backupA.SetAlive(false)
+	assert.Equal(t, primaryB, pp.NextAvailableBackend())
+
+	primaryB.SetAlive(false)
+	assert.Equal(t, backupB, pp.NextAvailableBackend())
+}
+
+func TestPriorityPool_RequestAwareTiers(t *testing.T) {
+	pp := NewPriorityPool(func() ServerPool {
+		return consistent_hash.Initialize(func(r *http.Request) string { return r.Header.Get("X-Gamer-Id") })
+	}, 1)
+	primaryA := newTieredBackend(t, "http://localhost:8085", 0)
+	primaryB := newTieredBackend(t, "http://localhost:8086", 0)
+	pp.RegisterServiceBackend(primaryA)
+	pp.RegisterServiceBackend(primaryB)
+	pp.RegisterServiceBackend(newTieredBackend(t, "http://standby:8085", 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/create", nil)
+	req.Header.Set("X-Gamer-Id", "GYUTDTE")
+	selected := SelectBackend(pp, req)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, selected, SelectBackend(pp, req))
+	}
+	assert.Contains(t, []backend.Backend{primaryA, primaryB}, selected)
+}
+
+func TestPriorityPool_RemoveBackend(t *testing.T) {
+	pp := NewPriorityPool(newRoundRobinTier, 1)
+	primary := newTieredBackend(t, "http://localhost:8085", 0)
+	backup := newTieredBackend(t, "http://standby:8085", 1)
+	pp.RegisterServiceBackend(primary)
+	pp.RegisterServiceBackend(backup)
+
+	pp.RemoveBackend(primary)
+	assert.Equal(t, int32(1), pp.GetServerPoolSize())
+	assert.Equal(t, backup, pp.NextAvailableBackend())
+}
+
+func TestPriorityPool_SelectionDoesNotAllocate(t *testing.T) {
+	pp := NewPriorityPool(newRoundRobinTier, 2)
+	pp.RegisterServiceBackend(newTieredBackend(t, "http://primary-1:8085", 0))
+	pp.RegisterServiceBackend(newTieredBackend(t, "http://primary-2:8085", 0))
+	pp.RegisterServiceBackend(newTieredBackend(t, "http://standby:8085", 1))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		pp.NextAvailableBackend()
+	})
+	assert.Zero(t, allocs)
+	assert.Equal(t, 3, pp.CountAvailableBackends())
+}
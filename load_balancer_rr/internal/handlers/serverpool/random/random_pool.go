@@ -0,0 +1,104 @@
+package random
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// Random represents a load balancer that picks an alive backend uniformly at random.
+// It keeps no shared rotation state, so concurrent requests do not contend on a counter.
+type Random struct {
+	Backends []backend.Backend
+	mux      sync.RWMutex // Mutex for synchronizing access
+}
+
+// Initialize initializes and returns a new Random instance.
+func Initialize() *Random {
+	var r Random
+	r.Backends = make([]backend.Backend, 0)
+	return &r
+}
+
+// NextAvailableBackend returns a random alive backend in the pool.
+func (r *Random) NextAvailableBackend() backend.Backend {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	aliveCount := 0
+	for _, b := range r.Backends {
+		if b.IsAvailable() {
+			aliveCount++
+		}
+	}
+	if aliveCount == 0 {
+		return nil
+	}
+
+	// Return the n-th alive backend, so that dead backends do not skew the distribution
+	n := rand.IntN(aliveCount)
+	for _, b := range r.Backends {
+		if !b.IsAvailable() {
+			continue
+		}
+		if n == 0 {
+			return b
+		}
+		n--
+	}
+	return nil
+}
+
+// ListServiceBackends returns all Backends in the pool.
+func (r *Random) ListServiceBackends() []backend.Backend {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	backendsCopy := make([]backend.Backend, len(r.Backends))
+	copy(backendsCopy, r.Backends)
+	return backendsCopy
+}
+
+// GetServerPoolSize returns the number of Backends in the pool.
+func (r *Random) GetServerPoolSize() int32 {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	return int32(len(r.Backends))
+}
+
+// CountAvailableBackends returns the number of Backends in the pool able to take new requests.
+func (r *Random) CountAvailableBackends() int {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	count := 0
+	for _, b := range r.Backends {
+		if b.IsAvailable() {
+			count++
+		}
+	}
+	return count
+}
+
+// RegisterServiceBackend adds a new backend to the pool.
+func (r *Random) RegisterServiceBackend(backend backend.Backend) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.Backends = append(r.Backends, backend)
+}
+
+// RemoveBackend removes a backend from the pool.
+func (r *Random) RemoveBackend(backend backend.Backend) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for i, b := range r.Backends {
+		if b == backend {
+			r.Backends = append(r.Backends[:i], r.Backends[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package random
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newBackend(t *testing.T, rawURL string) backend.Backend {
+	parsedURL, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	return backend.NewBackendServer(parsedURL, nil)
+}
+
+func TestInitialize(t *testing.T) {
+	r := Initialize()
+	assert.NotNil(t, r)
+	assert.Empty(t, r.Backends)
+	assert.Nil(t, r.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_Distribution(t *testing.T) {
+	r := Initialize()
+	for port := 8085; port <= 8087; port++ {
+		r.RegisterServiceBackend(newBackend(t, fmt.Sprintf("http://localhost:%d", port)))
+	}
+
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 3000; i++ {
+		counts[r.NextAvailableBackend()]++
+	}
+	assert.Len(t, counts, 3)
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 200)
+	}
+}
+
+func TestNextAvailableBackend_SkipsDeadBackends(t *testing.T) {
+	r := Initialize()
+	a := newBackend(t, "http://localhost:8085")
+	b := newBackend(t, "http://localhost:8086")
+	c := newBackend(t, "http://localhost:8087")
+	r.RegisterServiceBackend(a)
+	r.RegisterServiceBackend(b)
+	r.RegisterServiceBackend(c)
+
+	a.SetAlive(false)
+
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 2000; i++ {
+		counts[r.NextAvailableBackend()]++
+	}
+	assert.Zero(t, counts[a])
+	// The backend after the dead one must not inherit its share
+	assert.InDelta(t, 1000, counts[b], 200)
+	assert.InDelta(t, 1000, counts[c], 200)
+
+	b.SetAlive(false)
+	c.SetAlive(false)
+	assert.Nil(t, r.NextAvailableBackend())
+}
+
+func TestRegisterAndRemoveBackend(t *testing.T) {
+	r := Initialize()
+	a := newBackend(t, "http://localhost:8085")
+	b := newBackend(t, "http://localhost:8086")
+	r.RegisterServiceBackend(a)
+	r.RegisterServiceBackend(b)
+	assert.Equal(t, int32(2), r.GetServerPoolSize())
+
+	r.RemoveBackend(a)
+	assert.Equal(t, int32(1), r.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{b}, r.ListServiceBackends())
+}
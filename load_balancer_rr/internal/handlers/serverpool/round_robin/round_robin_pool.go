@@ -8,81 +8,101 @@ import (
 )
 
 // RoundRobin represents a round-robin load balancer for backends.
+// Readers load an immutable snapshot of the backends and advance the rotation with an atomic
+// fetch-add, so selecting a backend never takes a lock. Writers copy the snapshot, modify the
+// copy and swap it in atomically (read-copy-update).
 type RoundRobin struct {
-	Backends []backend.Backend
-	Current  atomic.Int32
-	mux      sync.RWMutex // Mutex for synchronizing access
+	backends atomic.Pointer[[]backend.Backend]
+	Current  atomic.Uint64
+	mux      sync.Mutex // Mutex for serializing writers
 }
 
 // Initialize initializes and returns a new RoundRobin instance.
 func Initialize() *RoundRobin {
 	var rr RoundRobin
-	rr.Backends = make([]backend.Backend, 0)
-	rr.Current.Store(0)
+	backends := make([]backend.Backend, 0)
+	rr.backends.Store(&backends)
 	return &rr
 }
 
 // NextAvailableBackend returns the next alive backend in the pool.
 func (roundRobin *RoundRobin) NextAvailableBackend() backend.Backend {
-	var i int32
-	for i = 0; i < roundRobin.GetServerPoolSize(); i++ {
-		nextPeer := roundRobin.Rotate()
-		alive := nextPeer.IsAlive()
-		if alive.Load() {
+	backends := *roundRobin.backends.Load()
+	size := uint64(len(backends))
+	if size == 0 {
+		return nil
+	}
+
+	// Claim a single slot in the rotation and scan the snapshot from there, so concurrent
+	// callers advancing the rotation cannot make a caller skip past every alive backend
+	start := roundRobin.Current.Add(1)
+	for i := uint64(0); i < size; i++ {
+		nextPeer := backends[(start+i)%size]
+		if nextPeer.IsAvailable() {
 			return nextPeer
 		}
 	}
 	return nil
 }
 
-// Rotate moves to the next backend in the round-robin rotation.
+// Rotate moves to the next backend in the round-robin rotation, it returns nil when the pool is empty.
 func (roundRobin *RoundRobin) Rotate() backend.Backend {
-	// Load the current index
-	currentIndex := roundRobin.Current.Load()
-
-	// Calculate the next index
-	nextIndex := (currentIndex + 1) % roundRobin.GetServerPoolSize()
-
-	// Store the updated index
-	roundRobin.Current.Store(nextIndex)
-
-	return roundRobin.Backends[nextIndex]
+	backends := *roundRobin.backends.Load()
+	if len(backends) == 0 {
+		return nil
+	}
+	return backends[roundRobin.Current.Add(1)%uint64(len(backends))]
 }
 
 // ListServiceBackends returns all Backends in the pool.
 func (roundRobin *RoundRobin) ListServiceBackends() []backend.Backend {
-	roundRobin.mux.RLock()         // Acquire a read lock
-	defer roundRobin.mux.RUnlock() // Ensure the read lock is released
+	backends := *roundRobin.backends.Load()
 
-	backendsCopy := make([]backend.Backend, len(roundRobin.Backends))
-	copy(backendsCopy, roundRobin.Backends)
+	backendsCopy := make([]backend.Backend, len(backends))
+	copy(backendsCopy, backends)
 	return backendsCopy
 }
 
 // GetServerPoolSize returns the number of Backends in the pool.
 func (roundRobin *RoundRobin) GetServerPoolSize() int32 {
-	roundRobin.mux.RLock()
-	defer roundRobin.mux.RUnlock()
+	return int32(len(*roundRobin.backends.Load()))
+}
 
-	return int32(len(roundRobin.Backends))
+// CountAvailableBackends returns the number of Backends in the pool able to take new requests.
+func (roundRobin *RoundRobin) CountAvailableBackends() int {
+	count := 0
+	for _, b := range *roundRobin.backends.Load() {
+		if b.IsAvailable() {
+			count++
+		}
+	}
+	return count
 }
 
 // RegisterServiceBackend adds a new backend to the pool.
-func (roundRobin *RoundRobin) RegisterServiceBackend(backend backend.Backend) {
-	roundRobin.mux.Lock()         // Acquire a write lock
-	defer roundRobin.mux.Unlock() //  Ensure the write lock is released
-
-	roundRobin.Backends = append(roundRobin.Backends, backend)
+func (roundRobin *RoundRobin) RegisterServiceBackend(newBackend backend.Backend) {
+	roundRobin.mux.Lock()         // Acquire the writer lock
+	defer roundRobin.mux.Unlock() // Ensure the writer lock is released
+
+	current := *roundRobin.backends.Load()
+	updated := make([]backend.Backend, len(current), len(current)+1)
+	copy(updated, current)
+	updated = append(updated, newBackend)
+	roundRobin.backends.Store(&updated)
 }
 
 // RemoveBackend removes a backend from the pool.
-func (roundRobin *RoundRobin) RemoveBackend(backend backend.Backend) {
+func (roundRobin *RoundRobin) RemoveBackend(removed backend.Backend) {
 	roundRobin.mux.Lock()
 	defer roundRobin.mux.Unlock()
 
-	for i, b := range roundRobin.Backends {
-		if b == backend {
-			roundRobin.Backends = append(roundRobin.Backends[:i], roundRobin.Backends[i+1:]...)
+	current := *roundRobin.backends.Load()
+	for i, b := range current {
+		if b == removed {
+			updated := make([]backend.Backend, 0, len(current)-1)
+			updated = append(updated, current[:i]...)
+			updated = append(updated, current[i+1:]...)
+			roundRobin.backends.Store(&updated)
 			break
 		}
 	}
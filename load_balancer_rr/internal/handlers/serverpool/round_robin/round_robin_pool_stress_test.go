@@ -0,0 +1,200 @@
+package round_robin
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newBackends(count int) []backend.Backend {
+	backends := make([]backend.Backend, 0, count)
+	for i := 0; i < count; i++ {
+		parsedURL, _ := url.Parse(fmt.Sprintf("http://localhost:%d", 9000+i))
+		backends = append(backends, backend.NewBackendServer(parsedURL, nil))
+	}
+	return backends
+}
+
+// TestNextAvailableBackend_ConcurrentEvenDistribution checks the fetch-add rotation hands
+// out every slot exactly once, so concurrent callers split the traffic exactly evenly.
+func TestNextAvailableBackend_ConcurrentEvenDistribution(t *testing.T) {
+	rr := Initialize()
+	backends := newBackends(4)
+	for _, b := range backends {
+		rr.RegisterServiceBackend(b)
+	}
+
+	const workers, picksPerWorker = 8, 1000
+	var mux sync.Mutex
+	counts := map[backend.Backend]int{}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := map[backend.Backend]int{}
+			for i := 0; i < picksPerWorker; i++ {
+				local[rr.NextAvailableBackend()]++
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			for b, count := range local {
+				counts[b] += count
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, b := range backends {
+		assert.Equal(t, workers*picksPerWorker/len(backends), counts[b])
+	}
+}
+
+// TestNextAvailableBackend_ConcurrentDeadBackends checks concurrent callers advancing the rotation
+// never make a caller miss the only alive backend of the pool.
+func TestNextAvailableBackend_ConcurrentDeadBackends(t *testing.T) {
+	rr := Initialize()
+	backends := newBackends(4)
+	for _, b := range backends {
+		rr.RegisterServiceBackend(b)
+	}
+	for _, b := range backends[1:] {
+		b.SetAlive(false)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5000; i++ {
+				if !assert.Equal(t, backends[0], rr.NextAvailableBackend()) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestNextAvailableBackend_ConcurrentRegisterRemove selects backends while others are registered,
+// removed and flip alive state. Run with -race to check the hot path is free of data races.
+func TestNextAvailableBackend_ConcurrentRegisterRemove(t *testing.T) {
+	rr := Initialize()
+	stable := newBackends(2)
+	for _, b := range stable {
+		rr.RegisterServiceBackend(b)
+	}
+	churn := newBackends(6)[2:]
+
+	known := map[backend.Backend]bool{}
+	for _, b := range append(stable, churn...) {
+		known[b] = true
+	}
+
+	var stop atomic.Bool
+	var writers sync.WaitGroup
+	writers.Add(2)
+	go func() {
+		defer writers.Done()
+		for !stop.Load() {
+			for _, b := range churn {
+				rr.RegisterServiceBackend(b)
+			}
+			for _, b := range churn {
+				rr.RemoveBackend(b)
+			}
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for !stop.Load() {
+			for _, b := range churn {
+				b.SetAlive(false)
+				b.SetAlive(true)
+			}
+		}
+	}()
+
+	var readers sync.WaitGroup
+	for r := 0; r < 8; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for i := 0; i < 5000; i++ {
+				selected := rr.NextAvailableBackend()
+				// The stable backends are always alive, so a backend is always found
+				if !assert.NotNil(t, selected) || !assert.True(t, known[selected]) {
+					return
+				}
+				rr.GetServerPoolSize()
+				rr.ListServiceBackends()
+			}
+		}()
+	}
+	readers.Wait()
+	stop.Store(true)
+	writers.Wait()
+
+	assert.Equal(t, int32(2), rr.GetServerPoolSize())
+}
+
+func BenchmarkNextAvailableBackend(b *testing.B) {
+	rr := Initialize()
+	for _, backend := range newBackends(8) {
+		rr.RegisterServiceBackend(backend)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rr.NextAvailableBackend()
+	}
+}
+
+func BenchmarkNextAvailableBackend_Parallel(b *testing.B) {
+	rr := Initialize()
+	for _, backend := range newBackends(8) {
+		rr.RegisterServiceBackend(backend)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rr.NextAvailableBackend()
+		}
+	})
+}
+
+func BenchmarkNextAvailableBackend_ParallelWithChurn(b *testing.B) {
+	rr := Initialize()
+	for _, backend := range newBackends(8) {
+		rr.RegisterServiceBackend(backend)
+	}
+	churn := newBackends(9)[8]
+
+	var stop atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for !stop.Load() {
+			rr.RegisterServiceBackend(churn)
+			rr.RemoveBackend(churn)
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rr.NextAvailableBackend()
+		}
+	})
+	b.StopTimer()
+	stop.Store(true)
+	<-done
+}
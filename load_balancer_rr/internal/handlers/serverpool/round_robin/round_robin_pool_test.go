@@ -7,23 +7,32 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
 )
 
 // MockBackend is a mock implementation of the backend.Backend interface
 type MockBackend struct {
+	// Backend satisfies the methods the round robin pool does not rely on
+	backend.Backend
 	alive   atomic.Bool
 	address string
 	url     *url.URL
 }
 
 // IsAlive returns the alive status of the backend.
-func (m *MockBackend) IsAlive() atomic.Bool {
-	return m.alive
+func (m *MockBackend) IsAlive() bool {
+	return m.alive.Load()
+}
+
+// IsAvailable reports the alive status of the backend, the mock is never ejected.
+func (m *MockBackend) IsAvailable() bool {
+	return m.alive.Load()
 }
 
 // SetAlive sets the alive status of the backend.
-func (m *MockBackend) SetAlive(alive atomic.Bool) {
-	m.alive.Store(alive.Load())
+func (m *MockBackend) SetAlive(alive bool) {
+	m.alive.Store(alive)
 }
 
 // GetAddress returns the address of the backend.
@@ -58,8 +67,10 @@ func (m *MockBackend) GetURL() *url.URL {
 func TestNew(t *testing.T) {
 	rr := Initialize()
 	assert.NotNil(t, rr)
-	assert.Equal(t, int32(0), rr.Current.Load())
-	assert.Empty(t, rr.Backends)
+	assert.Equal(t, uint64(0), rr.Current.Load())
+	assert.Empty(t, rr.ListServiceBackends())
+	assert.Nil(t, rr.Rotate())
+	assert.Nil(t, rr.NextAvailableBackend())
 }
 
 func TestRegisterServiceBackend(t *testing.T) {
@@ -67,8 +78,8 @@ func TestRegisterServiceBackend(t *testing.T) {
 	mockBackend := &MockBackend{}
 	rr.RegisterServiceBackend(mockBackend)
 
-	assert.Len(t, rr.Backends, 1)
-	assert.Equal(t, mockBackend, rr.Backends[0])
+	assert.Len(t, rr.ListServiceBackends(), 1)
+	assert.Equal(t, mockBackend, rr.ListServiceBackends()[0])
 }
 
 func TestRemoveBackend(t *testing.T) {
@@ -79,11 +90,11 @@ func TestRemoveBackend(t *testing.T) {
 	rr.RegisterServiceBackend(mockBackend2)
 
 	rr.RemoveBackend(mockBackend1)
-	assert.Len(t, rr.Backends, 1)
-	assert.Equal(t, mockBackend2, rr.Backends[0])
+	assert.Len(t, rr.ListServiceBackends(), 1)
+	assert.Equal(t, mockBackend2, rr.ListServiceBackends()[0])
 
 	rr.RemoveBackend(mockBackend2)
-	assert.Empty(t, rr.Backends)
+	assert.Empty(t, rr.ListServiceBackends())
 }
 
 func TestRotate(t *testing.T) {
@@ -95,27 +106,22 @@ func TestRotate(t *testing.T) {
 
 	rr.Current.Store(0)
 	assert.Equal(t, mockBackend2, rr.Rotate())
-	assert.Equal(t, int32(1), rr.Current.Load())
+	assert.Equal(t, uint64(1), rr.Current.Load())
 
 	assert.Equal(t, mockBackend1, rr.Rotate())
-	assert.Equal(t, int32(0), rr.Current.Load())
+	assert.Equal(t, uint64(2), rr.Current.Load())
 }
 
 func TestNextAvailableBackend(t *testing.T) {
 	rr := Initialize()
-	aliveStatusFalse := atomic.Bool{}
-	aliveStatusTrue := atomic.Bool{}
-
-	aliveStatusFalse.Store(false)
-	aliveStatusTrue.Store(true)
 
 	mockBackend1 := &MockBackend{}
 	mockBackend2 := &MockBackend{}
 	mockBackend3 := &MockBackend{}
-	mockBackend1.SetAlive(aliveStatusFalse)
+	mockBackend1.SetAlive(false)
 
-	mockBackend2.SetAlive(aliveStatusTrue)
-	mockBackend3.SetAlive(aliveStatusTrue)
+	mockBackend2.SetAlive(true)
+	mockBackend3.SetAlive(true)
 	rr.RegisterServiceBackend(mockBackend1)
 	rr.RegisterServiceBackend(mockBackend2)
 	rr.RegisterServiceBackend(mockBackend3)
@@ -124,11 +130,11 @@ func TestNextAvailableBackend(t *testing.T) {
 	assert.NotNil(t, backend)
 	assert.Equal(t, mockBackend2, backend)
 
-	mockBackend2.SetAlive(aliveStatusFalse)
+	mockBackend2.SetAlive(false)
 	backend = rr.NextAvailableBackend()
 	assert.Equal(t, mockBackend3, backend)
 
-	mockBackend3.SetAlive(aliveStatusFalse)
+	mockBackend3.SetAlive(false)
 	backend = rr.NextAvailableBackend()
 	assert.Nil(t, backend)
 }
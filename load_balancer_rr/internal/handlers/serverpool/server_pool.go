@@ -1,8 +1,21 @@
 package serverpool
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/constant"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/requestkey"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/consistent_hash"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/least_connections"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/peak_ewma"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/random"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/weighted_random"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/weighted_round_robin"
 )
 
 // ServerPool defines an interface for managing a pool of backend servers.
@@ -21,10 +34,126 @@ type ServerPool interface {
 
 	// GetServerPoolSize returns the total number of backends in the pool.
 	GetServerPoolSize() int32
+
+	// CountAvailableBackends returns the number of backends in the pool able to take new requests.
+	CountAvailableBackends() int
+}
+
+// RequestAwareServerPool is implemented by pools that select the backend based on the incoming request.
+type RequestAwareServerPool interface {
+	ServerPool
+
+	// NextAvailableBackendForRequest retrieves the next valid backend from the pool for the request.
+	NextAvailableBackendForRequest(r *http.Request) backend.Backend
+}
+
+// ActiveServerPool is implemented by pools that only select from part of their backends at a time,
+// such as the active priority tier or the local zone.
+type ActiveServerPool interface {
+	ServerPool
+
+	// ActiveBackends returns the backends the pool currently selects from.
+	ActiveBackends() []backend.Backend
+}
+
+// ActiveBackends returns the backends the pool currently selects from, every backend of the pool
+// unless it only selects from part of them.
+func ActiveBackends(sp ServerPool) []backend.Backend {
+	if active, ok := sp.(ActiveServerPool); ok {
+		return active.ActiveBackends()
+	}
+	return sp.ListServiceBackends()
+}
+
+// SelectBackend retrieves the next valid backend for the request, using the request
+// when the pool is request aware and the plain rotation otherwise.
+func SelectBackend(sp ServerPool, r *http.Request) backend.Backend {
+	if requestAware, ok := sp.(RequestAwareServerPool); ok {
+		return requestAware.NextAvailableBackendForRequest(r)
+	}
+	return sp.NextAvailableBackend()
 }
 
 // NewServerPool initializes and returns a new ServerPool instance.
-// It creates a RoundRobin server pool with an empty list of backends.
-func NewServerPool() (ServerPool, error) {
-	return round_robin.Initialize(), nil
+// It creates a server pool with an empty list of backends for the algorithm
+// configured for the backend, falling back to RoundRobin when none is set.
+// When routes are spread across zones, the backends in the load balancer zone are preferred,
+// and when routes are configured in several priority tiers, each tier gets its own pool.
+func NewServerPool(backendConfig config.Backend) (ServerPool, error) {
+	if _, err := newAlgorithmPool(backendConfig); err != nil {
+		return nil, err
+	}
+	// The config is validated above, so creating the pools below cannot fail
+	newPool := func() ServerPool {
+		pool, _ := newAlgorithmPool(backendConfig)
+		return pool
+	}
+
+	zoned, tiered := layout(backendConfig)
+	if zoned {
+		localZone := config.Config.Server.Zone
+		newAlgorithmPool := newPool
+		newPool = func() ServerPool {
+			return NewZonePool(localZone, newAlgorithmPool, backendConfig.MinLocalZoneBackends)
+		}
+	}
+
+	if tiered {
+		return NewPriorityPool(newPool, backendConfig.MinHealthyBackends), nil
+	}
+	return newPool(), nil
+}
+
+// ValidateRoute checks the pool built for the backend config honours the priority and zone of a route
+// added at runtime: the pool only has tiers and zones when the routes of the config file have them.
+func ValidateRoute(backendConfig config.Backend, route config.Route) error {
+	zoned, tiered := layout(backendConfig)
+	if route.Priority != 0 && !tiered {
+		return errors.New("priority is not supported, the service has no priority tiers")
+	}
+	if route.Zone != "" && !zoned {
+		return errors.New("zone is not supported, the service is not zone aware")
+	}
+	return nil
+}
+
+// layout reports whether the pool of the backend config prefers the local zone and is split into priority tiers.
+func layout(backendConfig config.Backend) (zoned bool, tiered bool) {
+	for _, route := range backendConfig.Routes {
+		zoned = zoned || route.Zone != ""
+		tiered = tiered || route.Priority != 0
+	}
+	return zoned && config.Config.Server.Zone != "", tiered
+}
+
+// newAlgorithmPool creates an empty server pool for the configured algorithm.
+func newAlgorithmPool(backendConfig config.Backend) (ServerPool, error) {
+	switch backendConfig.Algorithm {
+	case "", constant.RoundRobin:
+		return round_robin.Initialize(), nil
+	case constant.WeightedRoundRobin:
+		return weighted_round_robin.Initialize(), nil
+	case constant.LeastConnections:
+		return least_connections.Initialize(), nil
+	case constant.PeakEWMA:
+		return peak_ewma.Initialize(), nil
+	case constant.ConsistentHash:
+		keyFunc, err := requestkey.New(backendConfig.HashKey)
+		if err != nil {
+			return nil, err
+		}
+		return consistent_hash.Initialize(keyFunc), nil
+	case constant.ConsistentHashBoundedLoad:
+		keyFunc, err := requestkey.New(backendConfig.HashKey)
+		if err != nil {
+			return nil, err
+		}
+		return consistent_hash.InitializeBounded(keyFunc, backendConfig.BoundedLoadFactor), nil
+	case constant.Random:
+		return random.Initialize(), nil
+	case constant.WeightedRandom:
+		return weighted_random.Initialize(), nil
+	default:
+		return nil, fmt.Errorf("unsupported load balancing algorithm: %q", backendConfig.Algorithm)
+	}
 }
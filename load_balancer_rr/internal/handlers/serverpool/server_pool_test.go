@@ -0,0 +1,178 @@
+package serverpool
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/constant"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/consistent_hash"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/least_connections"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/peak_ewma"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/random"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/weighted_random"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/weighted_round_robin"
+)
+
+func TestNewServerPool(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		hashKey   config.RequestKey
+		expected  ServerPool
+		expectErr bool
+	}{
+		{name: "default algorithm", algorithm: "", expected: &round_robin.RoundRobin{}},
+		{name: "round robin", algorithm: constant.RoundRobin, expected: &round_robin.RoundRobin{}},
+		{name: "weighted round robin", algorithm: constant.WeightedRoundRobin, expected: &weighted_round_robin.WeightedRoundRobin{}},
+		{name: "least connections", algorithm: constant.LeastConnections, expected: &least_connections.LeastConnections{}},
+		{name: "peak ewma", algorithm: constant.PeakEWMA, expected: &peak_ewma.PeakEWMA{}},
+		{name: "consistent hash", algorithm: constant.ConsistentHash, hashKey: config.RequestKey{Source: constant.KeySourceHeader, Name: "X-Gamer-Id"}, expected: &consistent_hash.ConsistentHash{}},
+		{name: "consistent hash bounded load", algorithm: constant.ConsistentHashBoundedLoad, expected: &consistent_hash.ConsistentHash{}},
+		{name: "consistent hash bounded load invalid key", algorithm: constant.ConsistentHashBoundedLoad, hashKey: config.RequestKey{Source: "body"}, expectErr: true},
+		{name: "consistent hash invalid key", algorithm: constant.ConsistentHash, hashKey: config.RequestKey{Source: "body"}, expectErr: true},
+		{name: "random", algorithm: constant.Random, expected: &random.Random{}},
+		{name: "weighted random", algorithm: constant.WeightedRandom, expected: &weighted_random.WeightedRandom{}},
+		{name: "unknown algorithm", algorithm: "unknown", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := NewServerPool(config.Backend{Algorithm: tt.algorithm, HashKey: tt.hashKey})
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, pool)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, tt.expected, pool)
+		})
+	}
+}
+
+func TestCountAvailableBackends(t *testing.T) {
+	algorithms := []string{constant.RoundRobin, constant.WeightedRoundRobin, constant.LeastConnections, constant.PeakEWMA,
+		constant.ConsistentHash, constant.ConsistentHashBoundedLoad, constant.Random, constant.WeightedRandom}
+	for _, algorithm := range algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			pool, err := newAlgorithmPool(config.Backend{Algorithm: algorithm})
+			assert.NoError(t, err)
+
+			backends := []backend.Backend{
+				newTieredBackend(t, "http://localhost:8085", 0),
+				newTieredBackend(t, "http://localhost:8086", 0),
+				newTieredBackend(t, "http://localhost:8087", 0),
+			}
+			for _, b := range backends {
+				pool.RegisterServiceBackend(b)
+			}
+			backends[1].SetAlive(false)
+			backends[2].Eject(time.Now().Add(time.Minute))
+
+			assert.Equal(t, 1, pool.CountAvailableBackends())
+		})
+	}
+}
+
+func TestValidateRoute(t *testing.T) {
+	originalZone := config.Config.Server.Zone
+	defer func() { config.Config.Server.Zone = originalZone }()
+	config.Config.Server.Zone = "ap-southeast-1a"
+
+	flat := config.Backend{Routes: []config.Route{{URL: "http://localhost:8085"}}}
+	assert.NoError(t, ValidateRoute(flat, config.Route{URL: "http://localhost:8086"}))
+	assert.Error(t, ValidateRoute(flat, config.Route{URL: "http://localhost:8086", Priority: 1}))
+	assert.Error(t, ValidateRoute(flat, config.Route{URL: "http://localhost:8086", Zone: "ap-southeast-1b"}))
+
+	tiered := config.Backend{Routes: []config.Route{{URL: "http://localhost:8085"}, {URL: "http://standby:8085", Priority: 1}}}
+	assert.NoError(t, ValidateRoute(tiered, config.Route{URL: "http://standby:8086", Priority: 2}))
+
+	zoned := config.Backend{Routes: []config.Route{{URL: "http://10.0.1.10:8085", Zone: "ap-southeast-1a"}}}
+	assert.NoError(t, ValidateRoute(zoned, config.Route{URL: "http://10.0.2.10:8085", Zone: "ap-southeast-1b"}))
+
+	// Zone labels are only honoured when the load balancer has a zone
+	config.Config.Server.Zone = ""
+	assert.Error(t, ValidateRoute(zoned, config.Route{URL: "http://10.0.2.10:8085", Zone: "ap-southeast-1b"}))
+}
+
+func TestNewServerPool_PriorityTiers(t *testing.T) {
+	pool, err := NewServerPool(config.Backend{
+		Algorithm: constant.LeastConnections,
+		Routes: []config.Route{
+			{URL: "http://localhost:8085"},
+			{URL: "http://standby:8085", Priority: 1},
+		},
+		MinHealthyBackends: 2,
+	})
+	assert.NoError(t, err)
+	assert.IsType(t, &PriorityPool{}, pool)
+	assert.Equal(t, 2, pool.(*PriorityPool).minHealthy)
+
+	backendURL, _ := url.Parse("http://localhost:8085")
+	pool.RegisterServiceBackend(backend.NewBackendServer(backendURL, nil))
+	assert.IsType(t, &least_connections.LeastConnections{}, (*pool.(*PriorityPool).tiers.Load())[0].pool)
+
+	_, err = NewServerPool(config.Backend{
+		Algorithm: "unknown",
+		Routes:    []config.Route{{URL: "http://standby:8085", Priority: 1}},
+	})
+	assert.Error(t, err)
+}
+
+func TestNewServerPool_Zones(t *testing.T) {
+	originalZone := config.Config.Server.Zone
+	defer func() { config.Config.Server.Zone = originalZone }()
+
+	routes := []config.Route{
+		{URL: "http://10.0.1.1:8085", Zone: "zone-a"},
+		{URL: "http://10.0.2.1:8085", Zone: "zone-b"},
+	}
+
+	// Without a zone for the load balancer the routes zones are ignored
+	config.Config.Server.Zone = ""
+	pool, err := NewServerPool(config.Backend{Routes: routes})
+	assert.NoError(t, err)
+	assert.IsType(t, &round_robin.RoundRobin{}, pool)
+
+	config.Config.Server.Zone = "zone-a"
+	pool, err = NewServerPool(config.Backend{Routes: routes, MinLocalZoneBackends: 2})
+	assert.NoError(t, err)
+	assert.IsType(t, &ZonePool{}, pool)
+	assert.Equal(t, 2, pool.(*ZonePool).minLocal)
+
+	// Every priority tier prefers the local zone on its own
+	pool, err = NewServerPool(config.Backend{Routes: append(routes, config.Route{URL: "http://standby:8085", Zone: "zone-a", Priority: 1})})
+	assert.NoError(t, err)
+	assert.IsType(t, &PriorityPool{}, pool)
+	pool.RegisterServiceBackend(newZonedBackend(t, "http://10.0.1.1:8085", "zone-a"))
+	assert.IsType(t, &ZonePool{}, (*pool.(*PriorityPool).tiers.Load())[0].pool)
+}
+
+func TestSelectBackend(t *testing.T) {
+	first, _ := url.Parse("http://localhost:8085")
+	second, _ := url.Parse("http://localhost:8086")
+
+	// A plain pool rotates regardless of the request
+	rr := round_robin.Initialize()
+	rr.RegisterServiceBackend(backend.NewBackendServer(first, nil))
+	rr.RegisterServiceBackend(backend.NewBackendServer(second, nil))
+	req := httptest.NewRequest(http.MethodGet, "/create", nil)
+	assert.NotEqual(t, SelectBackend(rr, req), SelectBackend(rr, req))
+
+	// A request aware pool keeps routing the same request to the same backend
+	ch := consistent_hash.Initialize(func(r *http.Request) string { return r.Header.Get("X-Gamer-Id") })
+	ch.RegisterServiceBackend(backend.NewBackendServer(first, nil))
+	ch.RegisterServiceBackend(backend.NewBackendServer(second, nil))
+	req.Header.Set("X-Gamer-Id", "GYUTDTE")
+	selected := SelectBackend(ch, req)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, selected, SelectBackend(ch, req))
+	}
+}
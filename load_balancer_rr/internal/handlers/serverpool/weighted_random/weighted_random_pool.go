@@ -0,0 +1,107 @@
+package weighted_random
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// WeightedRandom represents a load balancer that picks an alive backend at random,
+// with a probability proportional to its weight.
+type WeightedRandom struct {
+	Backends []backend.Backend
+	mux      sync.RWMutex // Mutex for synchronizing access
+}
+
+// Initialize initializes and returns a new WeightedRandom instance.
+func Initialize() *WeightedRandom {
+	var wr WeightedRandom
+	wr.Backends = make([]backend.Backend, 0)
+	return &wr
+}
+
+// NextAvailableBackend returns a random alive backend in the pool, weighted by the backend effective weights.
+func (wr *WeightedRandom) NextAvailableBackend() backend.Backend {
+	wr.mux.RLock()
+	defer wr.mux.RUnlock()
+
+	totalWeight := 0.0
+	for _, b := range wr.Backends {
+		if b.IsAvailable() {
+			totalWeight += b.GetEffectiveWeight()
+		}
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	// Walk the alive backends until the random point falls within one's weight
+	point := rand.Float64() * totalWeight
+	var lastAlive backend.Backend
+	for _, b := range wr.Backends {
+		if !b.IsAvailable() {
+			continue
+		}
+		point -= b.GetEffectiveWeight()
+		if point < 0 {
+			return b
+		}
+		lastAlive = b
+	}
+	// Slow start weights keep growing between both passes, the point can land past the last backend
+	return lastAlive
+}
+
+// ListServiceBackends returns all Backends in the pool.
+func (wr *WeightedRandom) ListServiceBackends() []backend.Backend {
+	wr.mux.RLock()
+	defer wr.mux.RUnlock()
+
+	backendsCopy := make([]backend.Backend, len(wr.Backends))
+	copy(backendsCopy, wr.Backends)
+	return backendsCopy
+}
+
+// GetServerPoolSize returns the number of Backends in the pool.
+func (wr *WeightedRandom) GetServerPoolSize() int32 {
+	wr.mux.RLock()
+	defer wr.mux.RUnlock()
+
+	return int32(len(wr.Backends))
+}
+
+// CountAvailableBackends returns the number of Backends in the pool able to take new requests.
+func (wr *WeightedRandom) CountAvailableBackends() int {
+	wr.mux.RLock()
+	defer wr.mux.RUnlock()
+
+	count := 0
+	for _, b := range wr.Backends {
+		if b.IsAvailable() {
+			count++
+		}
+	}
+	return count
+}
+
+// RegisterServiceBackend adds a new backend to the pool.
+func (wr *WeightedRandom) RegisterServiceBackend(backend backend.Backend) {
+	wr.mux.Lock()
+	defer wr.mux.Unlock()
+
+	wr.Backends = append(wr.Backends, backend)
+}
+
+// RemoveBackend removes a backend from the pool.
+func (wr *WeightedRandom) RemoveBackend(backend backend.Backend) {
+	wr.mux.Lock()
+	defer wr.mux.Unlock()
+
+	for i, b := range wr.Backends {
+		if b == backend {
+			wr.Backends = append(wr.Backends[:i], wr.Backends[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package weighted_random
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newBackend(t *testing.T, rawURL string, weight int) backend.Backend {
+	parsedURL, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	return backend.NewBackendServer(parsedURL, nil, backend.WithWeight(weight))
+}
+
+func TestInitialize(t *testing.T) {
+	wr := Initialize()
+	assert.NotNil(t, wr)
+	assert.Empty(t, wr.Backends)
+	assert.Nil(t, wr.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_Proportions(t *testing.T) {
+	wr := Initialize()
+	heavy := newBackend(t, "http://localhost:8085", 3)
+	light := newBackend(t, "http://localhost:8086", 1)
+	wr.RegisterServiceBackend(heavy)
+	wr.RegisterServiceBackend(light)
+
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 4000; i++ {
+		counts[wr.NextAvailableBackend()]++
+	}
+	assert.InDelta(t, 3000, counts[heavy], 200)
+	assert.InDelta(t, 1000, counts[light], 200)
+}
+
+func TestNextAvailableBackend_SkipsDeadBackends(t *testing.T) {
+	wr := Initialize()
+	heavy := newBackend(t, "http://localhost:8085", 5)
+	light := newBackend(t, "http://localhost:8086", 1)
+	wr.RegisterServiceBackend(heavy)
+	wr.RegisterServiceBackend(light)
+
+	heavy.SetAlive(false)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, light, wr.NextAvailableBackend())
+	}
+
+	light.SetAlive(false)
+	assert.Nil(t, wr.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_SlowStart(t *testing.T) {
+	wr := Initialize()
+	warm := newBackend(t, "http://localhost:8085", 1)
+	parsedURL, _ := url.Parse("http://localhost:8086")
+	cold := backend.NewBackendServer(parsedURL, nil, backend.WithWeight(1), backend.WithSlowStart(time.Hour))
+	wr.RegisterServiceBackend(warm)
+	wr.RegisterServiceBackend(cold)
+
+	// The cold backend starts with a tenth of its weight, one pick in eleven
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 5500; i++ {
+		counts[wr.NextAvailableBackend()]++
+	}
+	assert.InDelta(t, 500, counts[cold], 150)
+}
+
+func TestRegisterAndRemoveBackend(t *testing.T) {
+	wr := Initialize()
+	a := newBackend(t, "http://localhost:8085", 1)
+	b := newBackend(t, "http://localhost:8086", 1)
+	wr.RegisterServiceBackend(a)
+	wr.RegisterServiceBackend(b)
+	assert.Equal(t, int32(2), wr.GetServerPoolSize())
+
+	wr.RemoveBackend(a)
+	assert.Equal(t, int32(1), wr.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{b}, wr.ListServiceBackends())
+}
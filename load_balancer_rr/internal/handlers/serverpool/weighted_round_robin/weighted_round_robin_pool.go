@@ -0,0 +1,110 @@
+package weighted_round_robin
+
+import (
+	"sync"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// weightedBackend keeps the running weight of a backend used by the smooth weighted selection.
+type weightedBackend struct {
+	backend       backend.Backend
+	currentWeight float64
+}
+
+// WeightedRoundRobin represents a smooth weighted round-robin load balancer for backends,
+// the same algorithm nginx uses for its upstreams.
+type WeightedRoundRobin struct {
+	backends []*weightedBackend
+	mux      sync.RWMutex // Mutex for synchronizing access
+}
+
+// Initialize initializes and returns a new WeightedRoundRobin instance.
+func Initialize() *WeightedRoundRobin {
+	var wrr WeightedRoundRobin
+	wrr.backends = make([]*weightedBackend, 0)
+	return &wrr
+}
+
+// NextAvailableBackend returns the alive backend with the highest current weight.
+// On every pick each alive backend gains its effective weight, and the chosen one loses the total,
+// which spreads the heavier backends evenly across the rotation instead of in bursts.
+func (wrr *WeightedRoundRobin) NextAvailableBackend() backend.Backend {
+	wrr.mux.Lock()
+	defer wrr.mux.Unlock()
+
+	var best *weightedBackend
+	totalWeight := 0.0
+	for _, wb := range wrr.backends {
+		if !wb.backend.IsAvailable() {
+			continue
+		}
+		weight := wb.backend.GetEffectiveWeight()
+		wb.currentWeight += weight
+		totalWeight += weight
+		if best == nil || wb.currentWeight > best.currentWeight {
+			best = wb
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+	best.currentWeight -= totalWeight
+	return best.backend
+}
+
+// ListServiceBackends returns all Backends in the pool.
+func (wrr *WeightedRoundRobin) ListServiceBackends() []backend.Backend {
+	wrr.mux.RLock()
+	defer wrr.mux.RUnlock()
+
+	backends := make([]backend.Backend, 0, len(wrr.backends))
+	for _, wb := range wrr.backends {
+		backends = append(backends, wb.backend)
+	}
+	return backends
+}
+
+// GetServerPoolSize returns the number of Backends in the pool.
+func (wrr *WeightedRoundRobin) GetServerPoolSize() int32 {
+	wrr.mux.RLock()
+	defer wrr.mux.RUnlock()
+
+	return int32(len(wrr.backends))
+}
+
+// CountAvailableBackends returns the number of Backends in the pool able to take new requests.
+func (wrr *WeightedRoundRobin) CountAvailableBackends() int {
+	wrr.mux.RLock()
+	defer wrr.mux.RUnlock()
+
+	count := 0
+	for _, wb := range wrr.backends {
+		if wb.backend.IsAvailable() {
+			count++
+		}
+	}
+	return count
+}
+
+// RegisterServiceBackend adds a new backend to the pool.
+func (wrr *WeightedRoundRobin) RegisterServiceBackend(backend backend.Backend) {
+	wrr.mux.Lock()
+	defer wrr.mux.Unlock()
+
+	wrr.backends = append(wrr.backends, &weightedBackend{backend: backend})
+}
+
+// RemoveBackend removes a backend from the pool.
+func (wrr *WeightedRoundRobin) RemoveBackend(backend backend.Backend) {
+	wrr.mux.Lock()
+	defer wrr.mux.Unlock()
+
+	for i, wb := range wrr.backends {
+		if wb.backend == backend {
+			wrr.backends = append(wrr.backends[:i], wrr.backends[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package weighted_round_robin
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newBackend(t *testing.T, rawURL string, weight int) backend.Backend {
+	parsedURL, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	return backend.NewBackendServer(parsedURL, nil, backend.WithWeight(weight))
+}
+
+func TestInitialize(t *testing.T) {
+	wrr := Initialize()
+	assert.NotNil(t, wrr)
+	assert.Empty(t, wrr.backends)
+	assert.Nil(t, wrr.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_SmoothDistribution(t *testing.T) {
+	wrr := Initialize()
+	a := newBackend(t, "http://localhost:8085", 5)
+	b := newBackend(t, "http://localhost:8086", 1)
+	c := newBackend(t, "http://localhost:8087", 1)
+	wrr.RegisterServiceBackend(a)
+	wrr.RegisterServiceBackend(b)
+	wrr.RegisterServiceBackend(c)
+
+	expected := []backend.Backend{a, a, b, a, c, a, a}
+	for i, want := range expected {
+		assert.Equal(t, want, wrr.NextAvailableBackend(), "pick %d", i)
+	}
+}
+
+func TestNextAvailableBackend_SkipsDeadBackends(t *testing.T) {
+	wrr := Initialize()
+	a := newBackend(t, "http://localhost:8085", 3)
+	b := newBackend(t, "http://localhost:8086", 1)
+	wrr.RegisterServiceBackend(a)
+	wrr.RegisterServiceBackend(b)
+
+	a.SetAlive(false)
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, b, wrr.NextAvailableBackend())
+	}
+
+	b.SetAlive(false)
+	assert.Nil(t, wrr.NextAvailableBackend())
+}
+
+func TestNextAvailableBackend_Proportions(t *testing.T) {
+	wrr := Initialize()
+	a := newBackend(t, "http://localhost:8085", 3)
+	b := newBackend(t, "http://localhost:8086", 2)
+	wrr.RegisterServiceBackend(a)
+	wrr.RegisterServiceBackend(b)
+
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 50; i++ {
+		counts[wrr.NextAvailableBackend()]++
+	}
+	assert.Equal(t, 30, counts[a])
+	assert.Equal(t, 20, counts[b])
+}
+
+func TestNextAvailableBackend_SlowStart(t *testing.T) {
+	wrr := Initialize()
+	warm := newBackend(t, "http://localhost:8085", 1)
+	parsedURL, _ := url.Parse("http://localhost:8086")
+	cold := backend.NewBackendServer(parsedURL, nil, backend.WithWeight(1), backend.WithSlowStart(time.Hour))
+	wrr.RegisterServiceBackend(warm)
+	wrr.RegisterServiceBackend(cold)
+
+	// The cold backend starts with a tenth of its weight, one pick in eleven
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 110; i++ {
+		counts[wrr.NextAvailableBackend()]++
+	}
+	assert.InDelta(t, 100, counts[warm], 1)
+	assert.InDelta(t, 10, counts[cold], 1)
+}
+
+func TestRegisterAndRemoveBackend(t *testing.T) {
+	wrr := Initialize()
+	a := newBackend(t, "http://localhost:8085", 1)
+	b := newBackend(t, "http://localhost:8086", 1)
+	wrr.RegisterServiceBackend(a)
+	wrr.RegisterServiceBackend(b)
+	assert.Equal(t, int32(2), wrr.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{a, b}, wrr.ListServiceBackends())
+
+	wrr.RemoveBackend(a)
+	assert.Equal(t, int32(1), wrr.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{b}, wrr.ListServiceBackends())
+}
@@ -0,0 +1,84 @@
+package serverpool
+
+import (
+	"net/http"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+// ZonePool prefers the backends in the same availability zone as the load balancer.
+// While fewer than minLocal of them are alive, requests spill over to every zone.
+type ZonePool struct {
+	localZone string
+	minLocal  int
+	// local holds the backends of the load balancer zone, all holds the backends of every zone
+	local ServerPool
+	all   ServerPool
+}
+
+// NewZonePool initializes and returns a new ZonePool for the zone, creating its pools with newPool.
+func NewZonePool(localZone string, newPool func() ServerPool, minLocal int) *ZonePool {
+	if minLocal < 1 {
+		minLocal = 1
+	}
+	return &ZonePool{
+		localZone: localZone,
+		minLocal:  minLocal,
+		local:     newPool(),
+		all:       newPool(),
+	}
+}
+
+// NextAvailableBackend retrieves the next valid backend, from the local zone when it is healthy enough.
+func (zp *ZonePool) NextAvailableBackend() backend.Backend {
+	return zp.activePool().NextAvailableBackend()
+}
+
+// NextAvailableBackendForRequest retrieves the next valid backend for the request, from the local zone when it is healthy enough.
+func (zp *ZonePool) NextAvailableBackendForRequest(r *http.Request) backend.Backend {
+	return SelectBackend(zp.activePool(), r)
+}
+
+// ActiveBackends returns the backends of the local zone when it is healthy enough, and of every zone otherwise.
+func (zp *ZonePool) ActiveBackends() []backend.Backend {
+	return ActiveBackends(zp.activePool())
+}
+
+// activePool returns the local pool while it has at least minLocal available backends, and the pool of every zone otherwise.
+func (zp *ZonePool) activePool() ServerPool {
+	if zp.local.CountAvailableBackends() >= zp.minLocal {
+		return zp.local
+	}
+	return zp.all
+}
+
+// ListServiceBackends returns the backends of every zone.
+func (zp *ZonePool) ListServiceBackends() []backend.Backend {
+	return zp.all.ListServiceBackends()
+}
+
+// GetServerPoolSize returns the number of backends across every zone.
+func (zp *ZonePool) GetServerPoolSize() int32 {
+	return zp.all.GetServerPoolSize()
+}
+
+// CountAvailableBackends returns the number of backends across every zone able to take new requests.
+func (zp *ZonePool) CountAvailableBackends() int {
+	return zp.all.CountAvailableBackends()
+}
+
+// RegisterServiceBackend adds a new backend to the pool, and to the local pool when it is in the load balancer zone.
+func (zp *ZonePool) RegisterServiceBackend(backend backend.Backend) {
+	if backend.GetZone() == zp.localZone {
+		zp.local.RegisterServiceBackend(backend)
+	}
+	zp.all.RegisterServiceBackend(backend)
+}
+
+// RemoveBackend removes the backend from the pool.
+func (zp *ZonePool) RemoveBackend(backend backend.Backend) {
+	if backend.GetZone() == zp.localZone {
+		zp.local.RemoveBackend(backend)
+	}
+	zp.all.RemoveBackend(backend)
+}
@@ -0,0 +1,79 @@
+package serverpool
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newZonedBackend(t *testing.T, rawURL string, zone string) backend.Backend {
+	parsedURL, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	return backend.NewBackendServer(parsedURL, nil, backend.WithZone(zone))
+}
+
+func TestZonePool_PrefersLocalZone(t *testing.T) {
+	zp := NewZonePool("zone-a", newRoundRobinTier, 0)
+	localA := newZonedBackend(t, "http://10.0.1.1:8085", "zone-a")
+	localB := newZonedBackend(t, "http://10.0.1.2:8085", "zone-a")
+	remote := newZonedBackend(t, "http://10.0.2.1:8085", "zone-b")
+	zp.RegisterServiceBackend(localA)
+	zp.RegisterServiceBackend(remote)
+	zp.RegisterServiceBackend(localB)
+
+	assert.Equal(t, int32(3), zp.GetServerPoolSize())
+	assert.Equal(t, []backend.Backend{localA, remote, localB}, zp.ListServiceBackends())
+
+	for i := 0; i < 6; i++ {
+		assert.NotEqual(t, remote, zp.NextAvailableBackend())
+	}
+}
+
+func TestZonePool_SpillsOverBelowThreshold(t *testing.T) {
+	zp := NewZonePool("zone-a", newRoundRobinTier, 2)
+	localA := newZonedBackend(t, "http://10.0.1.1:8085", "zone-a")
+	localB := newZonedBackend(t, "http://10.0.1.2:8085", "zone-a")
+	remote := newZonedBackend(t, "http://10.0.2.1:8085", "zone-b")
+	zp.RegisterServiceBackend(localA)
+	zp.RegisterServiceBackend(localB)
+	zp.RegisterServiceBackend(remote)
+
+	// With one local backend left, traffic is shared between it and the other zones,
+	// the turn of the dead backend going to the next alive one
+	localA.SetAlive(false)
+	counts := map[backend.Backend]int{}
+	for i := 0; i < 6; i++ {
+		counts[zp.NextAvailableBackend()]++
+	}
+	assert.Equal(t, map[backend.Backend]int{localB: 4, remote: 2}, counts)
+
+	assert.Equal(t, []backend.Backend{localA, localB, remote}, ActiveBackends(zp))
+
+	// Once the local zone recovers, traffic stays local again
+	localA.SetAlive(true)
+	for i := 0; i < 4; i++ {
+		assert.NotEqual(t, remote, zp.NextAvailableBackend())
+	}
+	assert.Equal(t, []backend.Backend{localA, localB}, ActiveBackends(zp))
+
+	localA.SetAlive(false)
+	localB.SetAlive(false)
+	remote.SetAlive(false)
+	assert.Nil(t, zp.NextAvailableBackend())
+}
+
+func TestZonePool_RemoveBackend(t *testing.T) {
+	zp := NewZonePool("zone-a", newRoundRobinTier, 1)
+	local := newZonedBackend(t, "http://10.0.1.1:8085", "zone-a")
+	remote := newZonedBackend(t, "http://10.0.2.1:8085", "zone-b")
+	zp.RegisterServiceBackend(local)
+	zp.RegisterServiceBackend(remote)
+
+	zp.RemoveBackend(local)
+	assert.Equal(t, int32(1), zp.GetServerPoolSize())
+	assert.Equal(t, int32(0), zp.local.GetServerPoolSize())
+	assert.Equal(t, remote, zp.NextAvailableBackend())
+}
@@ -0,0 +1,156 @@
+package sticky_session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+const (
+	// DefaultCookieName is used when no cookie name is configured
+	DefaultCookieName = "lb_backend"
+	// DefaultTTL is used when no cookie TTL is configured
+	DefaultTTL = time.Hour
+)
+
+// StickySession pins clients to a backend with a signed cookie.
+// The cookie value is an opaque ID of the backend and the expiry followed by their HMAC-SHA256 signature,
+// so the internal backend URLs are never exposed to clients.
+type StickySession struct {
+	cookieName string
+	ttl        time.Duration
+	signingKey []byte
+}
+
+// New initializes and returns a StickySession from the config.
+func New(stickySession config.StickySession) (*StickySession, error) {
+	if stickySession.SigningKey == "" {
+		return nil, errors.New("sticky session signing key is required")
+	}
+
+	ss := &StickySession{
+		cookieName: stickySession.CookieName,
+		ttl:        time.Duration(stickySession.TTL) * time.Second,
+		signingKey: []byte(stickySession.SigningKey),
+	}
+	if ss.cookieName == "" {
+		ss.cookieName = DefaultCookieName
+	}
+	if ss.ttl <= 0 {
+		ss.ttl = DefaultTTL
+	}
+	return ss, nil
+}
+
+// ServiceCookieName returns the default cookie name of a service, so that the services of one load balancer
+// do not overwrite each other's cookie. Characters not allowed in a cookie name are replaced by an underscore.
+func ServiceCookieName(service string) string {
+	name := []byte(DefaultCookieName + "_" + service)
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
+
+// Lookup returns the backend pinned by the request cookie while it is alive,
+// it returns nil when the cookie is missing, invalid, expired or the backend is dead.
+func (ss *StickySession) Lookup(r *http.Request, backends []backend.Backend) backend.Backend {
+	cookie, err := r.Cookie(ss.cookieName)
+	if err != nil {
+		return nil
+	}
+
+	id, ok := ss.verify(cookie.Value, time.Now())
+	if !ok {
+		return nil
+	}
+
+	for _, b := range backends {
+		if backendID(b) != id {
+			continue
+		}
+		if b.IsAvailable() {
+			return b
+		}
+		return nil
+	}
+	return nil
+}
+
+// Pin sets the cookie pinning the client to the backend on the response. It is set on every response
+// served by the pinned backend, so the expiry slides while the client keeps using it.
+func (ss *StickySession) Pin(w http.ResponseWriter, b backend.Backend) {
+	expiry := time.Now().Add(ss.ttl)
+	http.SetCookie(w, &http.Cookie{
+		Name:     ss.cookieName,
+		Value:    ss.sign(backendID(b), expiry),
+		Path:     "/",
+		Expires:  expiry,
+		MaxAge:   int(ss.ttl.Seconds()),
+		HttpOnly: true,
+	})
+}
+
+// Unpin removes the cookie set by Pin from a response not written yet, when the backend it pinned
+// refused the request.
+func (ss *StickySession) Unpin(w http.ResponseWriter) {
+	prefix := ss.cookieName + "="
+	kept := make([]string, 0)
+	for _, cookie := range w.Header().Values("Set-Cookie") {
+		if !strings.HasPrefix(cookie, prefix) {
+			kept = append(kept, cookie)
+		}
+	}
+	w.Header().Del("Set-Cookie")
+	for _, cookie := range kept {
+		w.Header().Add("Set-Cookie", cookie)
+	}
+}
+
+// sign encodes the backend ID and expiry along with their signature.
+func (ss *StickySession) sign(id string, expiry time.Time) string {
+	payload := id + "." + strconv.FormatInt(expiry.Unix(), 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(ss.mac(payload))
+}
+
+// verify checks the signature and expiry of the cookie value and returns the backend ID.
+func (ss *StickySession) verify(value string, now time.Time) (string, bool) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, ss.mac(parts[0]+"."+parts[1])) {
+		return "", false
+	}
+
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > expiry {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// mac computes the HMAC-SHA256 of the payload with the signing key.
+func (ss *StickySession) mac(payload string) []byte {
+	hash := hmac.New(sha256.New, ss.signingKey)
+	hash.Write([]byte(payload))
+	return hash.Sum(nil)
+}
+
+// backendID returns the opaque ID of the backend stored in the cookie, a truncated SHA-256 of its URL.
+func backendID(b backend.Backend) string {
+	sum := sha256.Sum256([]byte(b.GetURL().String()))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
@@ -0,0 +1,150 @@
+package sticky_session
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+)
+
+func newBackends() []backend.Backend {
+	first, _ := url.Parse("http://localhost:8085")
+	second, _ := url.Parse("http://localhost:8086")
+	return []backend.Backend{backend.NewBackendServer(first, nil), backend.NewBackendServer(second, nil)}
+}
+
+// pinnedRequest returns a request carrying the cookie set by Pin for the backend.
+func pinnedRequest(ss *StickySession, b backend.Backend) *http.Request {
+	rec := httptest.NewRecorder()
+	ss.Pin(rec, b)
+
+	req := httptest.NewRequest(http.MethodGet, "/create", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(config.StickySession{Enabled: true})
+	assert.Error(t, err)
+
+	ss, err := New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCookieName, ss.cookieName)
+	assert.Equal(t, DefaultTTL, ss.ttl)
+
+	ss, err = New(config.StickySession{Enabled: true, CookieName: "game_backend", TTL: 60, SigningKey: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, "game_backend", ss.cookieName)
+	assert.Equal(t, time.Minute, ss.ttl)
+}
+
+func TestServiceCookieName(t *testing.T) {
+	assert.Equal(t, "lb_backend_game", ServiceCookieName("game"))
+	assert.Equal(t, "lb_backend_payments-api", ServiceCookieName("payments-api"))
+	assert.Equal(t, "lb_backend_auth_api_v1", ServiceCookieName("auth api/v1"))
+}
+
+func TestPinAndLookup(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, CookieName: "game_backend", TTL: 60, SigningKey: "secret"})
+	backends := newBackends()
+
+	rec := httptest.NewRecorder()
+	ss.Pin(rec, backends[1])
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "game_backend", cookies[0].Name)
+	assert.Equal(t, 60, cookies[0].MaxAge)
+	assert.True(t, cookies[0].HttpOnly)
+
+	assert.Equal(t, backends[1], ss.Lookup(pinnedRequest(ss, backends[1]), backends))
+}
+
+func TestPin_OpaqueBackend(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	backends := newBackends()
+
+	rec := httptest.NewRecorder()
+	ss.Pin(rec, backends[0])
+	value := rec.Result().Cookies()[0].Value
+	for _, encoded := range []string{"localhost", base64.RawURLEncoding.EncodeToString([]byte("http://localhost:8085"))} {
+		assert.NotContains(t, value, encoded)
+	}
+	assert.NotEqual(t, backendID(backends[0]), backendID(backends[1]))
+}
+
+func TestUnpin(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, CookieName: "game_backend", SigningKey: "secret"})
+	backends := newBackends()
+
+	rec := httptest.NewRecorder()
+	http.SetCookie(rec, &http.Cookie{Name: "game_backend_theme", Value: "dark"})
+	ss.Pin(rec, backends[0])
+	ss.Unpin(rec)
+	ss.Pin(rec, backends[1])
+
+	// Only the pin to the backend serving the request is left, along with the other cookies
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, "game_backend_theme", cookies[0].Name)
+
+	req := httptest.NewRequest(http.MethodGet, "/create", nil)
+	req.AddCookie(cookies[1])
+	assert.Equal(t, backends[1], ss.Lookup(req, backends))
+}
+
+func TestLookup_NoCookie(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	assert.Nil(t, ss.Lookup(httptest.NewRequest(http.MethodGet, "/create", nil), newBackends()))
+}
+
+func TestLookup_DeadBackend(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	backends := newBackends()
+	req := pinnedRequest(ss, backends[0])
+
+	backends[0].SetAlive(false)
+	assert.Nil(t, ss.Lookup(req, backends))
+}
+
+func TestLookup_RemovedBackend(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	backends := newBackends()
+	req := pinnedRequest(ss, backends[0])
+
+	assert.Nil(t, ss.Lookup(req, backends[1:]))
+}
+
+func TestLookup_InvalidSignature(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	other, _ := New(config.StickySession{Enabled: true, SigningKey: "other-secret"})
+	backends := newBackends()
+
+	assert.Nil(t, ss.Lookup(pinnedRequest(other, backends[0]), backends))
+
+	req := httptest.NewRequest(http.MethodGet, "/create", nil)
+	req.AddCookie(&http.Cookie{Name: DefaultCookieName, Value: "not-a-valid-value"})
+	assert.Nil(t, ss.Lookup(req, backends))
+}
+
+func TestVerify_Expired(t *testing.T) {
+	ss, _ := New(config.StickySession{Enabled: true, SigningKey: "secret"})
+	now := time.Now()
+	id := backendID(newBackends()[0])
+	value := ss.sign(id, now.Add(time.Minute))
+
+	verified, ok := ss.verify(value, now)
+	assert.True(t, ok)
+	assert.Equal(t, id, verified)
+
+	_, ok = ss.verify(value, now.Add(2*time.Minute))
+	assert.False(t, ok)
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/circuit_breaker"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/load_balancer"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/outlier"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/router"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/sticky_session"
+)
+
+// DefaultServiceName is the name of the service built from the top level backend config,
+// it serves the requests that match no other service.
+const DefaultServiceName = "default"
+
+// defaultDialTimeout is used to connect to the backends when the service sets no dial timeout.
+const defaultDialTimeout = 30 * time.Second
+
+// ConfiguredServices returns the services of the config, along with the default service
+// when backend routes are configured.
+func ConfiguredServices() ([]config.Service, error) {
+	services := make([]config.Service, 0, len(config.Config.Services)+1)
+	names := map[string]bool{DefaultServiceName: true}
+	for _, service := range config.Config.Services {
+		if service.Name == "" {
+			return nil, errors.New("service name is required")
+		}
+		if names[service.Name] {
+			return nil, fmt.Errorf("duplicate service name: %q", service.Name)
+		}
+		names[service.Name] = true
+		services = append(services, service)
+	}
+
+	if len(config.Config.Backend.Routes) > 0 {
+		services = append(services, config.Service{Name: DefaultServiceName, Backend: config.Config.Backend})
+	}
+
+	if len(services) == 0 {
+		return nil, errors.New("no backend routes or services configured")
+	}
+	return services, nil
+}
+
+// NewRoute creates the server pool of the service, registers its backends and sets up its load balancer.
+func NewRoute(service config.Service) (*router.Route, error) {
+	// Initialize a new server pool with lb algorithm
+	serverPool, err := serverpool.NewServerPool(service.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	lbOptions := make([]load_balancer.Option, 0)
+	if service.StickySession.Enabled {
+		stickyConfig := service.StickySession
+		if stickyConfig.CookieName == "" && service.Name != DefaultServiceName {
+			// Each service keeps its own pin, as a client may use several of them
+			stickyConfig.CookieName = sticky_session.ServiceCookieName(service.Name)
+		}
+		stickySession, stickyErr := sticky_session.New(stickyConfig)
+		if stickyErr != nil {
+			return nil, stickyErr
+		}
+		lbOptions = append(lbOptions, load_balancer.WithStickySession(stickySession))
+	}
+
+	detector := outlier.New(service.OutlierDetection, serverPool)
+	for _, route := range service.Routes {
+		backendServer, backendErr := NewBackend(service, route, detector)
+		if backendErr != nil {
+			return nil, backendErr
+		}
+
+		serverPool.RegisterServiceBackend(backendServer)
+
+		config.Logger.Info("added server", zap.String("service", service.Name), zap.String("host: ", backendServer.GetURL().Host),
+			zap.Int("weight", backendServer.GetWeight()), zap.Int("priority", backendServer.GetPriority()), zap.String("zone", backendServer.GetZone()))
+	}
+
+	return &router.Route{
+		Service:      service,
+		Pool:         serverPool,
+		LoadBalancer: load_balancer.NewLoadBalancer(serverPool, lbOptions...),
+		NewBackend: func(route config.Route) (backend.Backend, error) {
+			return NewBackend(service, route, detector, backend.WithPending())
+		},
+	}, nil
+}
+
+// NewBackend creates the backend server of a route, proxying to it with the timeouts of the service.
+// The responses and proxy errors of the backend feed the outlier detector of the pool when it is set,
+// and the backend is wrapped in a circuit breaker when the service configures one.
+// The options are applied to the backend server after the ones of the route.
+func NewBackend(service config.Service, route config.Route, detector *outlier.Detector, opts ...backend.Option) (backend.Backend, error) {
+	// Parse backend URLs and add them to the server pool
+	parsedURL, err := url.Parse(route.URL)
+	if err != nil {
+		// Push alert here: URL parsing failed
+		return nil, fmt.Errorf("invalid backend URL %q: %w", route.URL, err)
+	}
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("invalid backend URL %q: scheme and host are required", route.URL)
+	}
+
+	// Create a reverse proxy for the backend
+	reverseProxy := httputil.NewSingleHostReverseProxy(parsedURL)
+	reverseProxy.Transport = newTransport(service.Backend)
+	reverseProxy.ErrorHandler = backend.ProxyErrorHandler
+
+	// Create a new backend server
+	options := []backend.Option{
+		backend.WithWeight(route.Weight),
+		backend.WithPriority(route.Priority),
+		backend.WithZone(route.Zone),
+		backend.WithSlowStart(time.Duration(service.SlowStart) * time.Second),
+	}
+	backendServer := circuit_breaker.Wrap(service.Name, service.CircuitBreaker,
+		backend.NewBackendServer(parsedURL, reverseProxy, append(options, opts...)...))
+
+	if detector != nil {
+		// The detector keeps the stats of the backend registered in the pool, the circuit breaker when there is one
+		reverseProxy.ModifyResponse = detector.ModifyResponse(backendServer)
+		reverseProxy.ErrorHandler = detector.ErrorHandler(backendServer)
+	}
+	return backendServer, nil
+}
+
+// newTransport returns the HTTP transport to the backends with the timeouts of the service.
+func newTransport(backendConfig config.Backend) *http.Transport {
+	dialTimeout := defaultDialTimeout
+	if backendConfig.DialTimeout > 0 {
+		dialTimeout = time.Duration(backendConfig.DialTimeout) * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = time.Duration(backendConfig.ResponseTimeout) * time.Second
+	return transport
+}
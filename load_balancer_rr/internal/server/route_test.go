@@ -0,0 +1,239 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/circuit_breaker"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/outlier"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool/round_robin"
+)
+
+// withConfig runs the test with the services and top level backend config, restoring the config afterwards.
+func withConfig(t *testing.T, services []config.Service, backendConfig config.Backend) {
+	originalServices, originalBackend := config.Config.Services, config.Config.Backend
+	t.Cleanup(func() {
+		config.Config.Services, config.Config.Backend = originalServices, originalBackend
+	})
+	config.Config.Services, config.Config.Backend = services, backendConfig
+}
+
+// newStatusServer returns a backend responding with the status to every request.
+func newStatusServer(t *testing.T, status int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConfiguredServices(t *testing.T) {
+	routes := config.Backend{Routes: []config.Route{{URL: "http://localhost:8085"}}}
+
+	tests := []struct {
+		name          string
+		services      []config.Service
+		backend       config.Backend
+		expectedNames []string
+		expectedErr   string
+	}{
+		{name: "default service only", backend: routes, expectedNames: []string{DefaultServiceName}},
+		{
+			name:          "default service last",
+			services:      []config.Service{{Name: "game", Backend: routes}, {Name: "auth", Backend: routes}},
+			backend:       routes,
+			expectedNames: []string{"game", "auth", DefaultServiceName},
+		},
+		{
+			name:          "no default service without backend routes",
+			services:      []config.Service{{Name: "game", Backend: routes}},
+			expectedNames: []string{"game"},
+		},
+		{
+			name:        "duplicate name",
+			services:    []config.Service{{Name: "game", Backend: routes}, {Name: "game", Backend: routes}},
+			expectedErr: `duplicate service name: "game"`,
+		},
+		{
+			name:        "default name reserved",
+			services:    []config.Service{{Name: DefaultServiceName, Backend: routes}},
+			expectedErr: `duplicate service name: "default"`,
+		},
+		{name: "missing name", services: []config.Service{{Backend: routes}}, expectedErr: "service name is required"},
+		{name: "nothing configured", expectedErr: "no backend routes or services configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, tt.services, tt.backend)
+
+			services, err := ConfiguredServices()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			names := make([]string, 0, len(services))
+			for _, service := range services {
+				names = append(names, service.Name)
+			}
+			assert.Equal(t, tt.expectedNames, names)
+		})
+	}
+
+	// The default service takes the top level backend config
+	withConfig(t, nil, routes)
+	services, err := ConfiguredServices()
+	assert.NoError(t, err)
+	assert.Equal(t, routes, services[0].Backend)
+}
+
+func TestNewRoute_StickyCookieName(t *testing.T) {
+	server := newStatusServer(t, http.StatusOK)
+
+	tests := []struct {
+		name       string
+		service    string
+		cookieName string
+		expected   string
+	}{
+		{name: "named service", service: "game", expected: "lb_backend_game"},
+		{name: "sanitized service name", service: "game api", expected: "lb_backend_game_api"},
+		{name: "default service", service: DefaultServiceName, expected: "lb_backend"},
+		{name: "configured cookie name", service: "game", cookieName: "game_session", expected: "game_session"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, err := NewRoute(config.Service{Name: tt.service, Backend: config.Backend{
+				Routes:        []config.Route{{URL: server.URL}},
+				StickySession: config.StickySession{Enabled: true, CookieName: tt.cookieName, SigningKey: "secret"},
+			}})
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			route.LoadBalancer.Serve(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			cookies := rr.Result().Cookies()
+			if assert.Len(t, cookies, 1) {
+				assert.Equal(t, tt.expected, cookies[0].Name)
+			}
+		})
+	}
+
+	_, err := NewRoute(config.Service{Name: "game", Backend: config.Backend{
+		Routes:        []config.Route{{URL: server.URL}},
+		StickySession: config.StickySession{Enabled: true},
+	}})
+	assert.EqualError(t, err, "sticky session signing key is required")
+}
+
+func TestNewRoute_RegistersBackends(t *testing.T) {
+	route, err := NewRoute(config.Service{Name: "game", Backend: config.Backend{Routes: []config.Route{
+		{URL: "http://localhost:8085", Weight: 2},
+		{URL: "http://localhost:8086"},
+	}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "game", route.Service.Name)
+
+	backends := route.Pool.ListServiceBackends()
+	if assert.Len(t, backends, 2) {
+		assert.Equal(t, "http://localhost:8085", backends[0].GetURL().String())
+		assert.Equal(t, 2, backends[0].GetWeight())
+		assert.False(t, backends[0].IsPending())
+	}
+
+	// The backends added at runtime stay out of rotation until their first health check
+	added, err := route.NewBackend(config.Route{URL: "http://localhost:8087"})
+	assert.NoError(t, err)
+	assert.True(t, added.IsPending())
+	assert.False(t, added.IsAvailable())
+
+	_, err = NewRoute(config.Service{Name: "game", Backend: config.Backend{Routes: []config.Route{{URL: "localhost"}}}})
+	assert.Error(t, err)
+}
+
+func TestNewBackend(t *testing.T) {
+	service := config.Service{Name: "game"}
+
+	b, err := NewBackend(service, config.Route{URL: "http://localhost:8085", Weight: 3, Priority: 1, Zone: "ap-southeast-1a"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, b.GetWeight())
+	assert.Equal(t, 1, b.GetPriority())
+	assert.Equal(t, "ap-southeast-1a", b.GetZone())
+
+	for _, rawURL := range []string{"localhost:8085", "/healthcheck", "http://", ":invalid"} {
+		_, err = NewBackend(service, config.Route{URL: rawURL}, nil)
+		assert.Error(t, err, rawURL)
+	}
+}
+
+func TestNewBackend_CircuitBreaker(t *testing.T) {
+	b, err := NewBackend(config.Service{Name: "game"}, config.Route{URL: "http://localhost:8085"}, nil)
+	assert.NoError(t, err)
+	_, wrapped := b.(*circuit_breaker.CircuitBreaker)
+	assert.False(t, wrapped)
+
+	service := config.Service{Name: "game", Backend: config.Backend{CircuitBreaker: config.CircuitBreaker{FailureRate: 50}}}
+	b, err = NewBackend(service, config.Route{URL: "http://localhost:8085"}, nil, backend.WithPending())
+	assert.NoError(t, err)
+	breaker, wrapped := b.(*circuit_breaker.CircuitBreaker)
+	if assert.True(t, wrapped) {
+		assert.Equal(t, circuit_breaker.Closed, breaker.State())
+		assert.True(t, breaker.IsPending())
+	}
+}
+
+func TestNewBackend_OutlierDetection(t *testing.T) {
+	server := newStatusServer(t, http.StatusInternalServerError)
+	pool := round_robin.Initialize()
+	detector := outlier.New(config.OutlierDetection{ConsecutiveErrors: 2}, pool)
+
+	b, err := NewBackend(config.Service{Name: "game"}, config.Route{URL: server.URL}, detector)
+	assert.NoError(t, err)
+	pool.RegisterServiceBackend(b)
+
+	// The responses of the backend feed the detector, which ejects it after consecutive errors
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		b.Serve(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	}
+	assert.True(t, b.IsEjected())
+	assert.False(t, b.IsAvailable())
+}
+
+func TestNewBackend_OutlierDetectionWithCircuitBreaker(t *testing.T) {
+	server := newStatusServer(t, http.StatusInternalServerError)
+	pool := round_robin.Initialize()
+	detector := outlier.New(config.OutlierDetection{ConsecutiveErrors: 2, Window: 1, BaseEjectionTime: 1}, pool)
+
+	service := config.Service{Name: "game", Backend: config.Backend{CircuitBreaker: config.CircuitBreaker{FailureRate: 100}}}
+	b, err := NewBackend(service, config.Route{URL: server.URL}, detector)
+	assert.NoError(t, err)
+	_, wrapped := b.(*circuit_breaker.CircuitBreaker)
+	assert.True(t, wrapped)
+	pool.RegisterServiceBackend(b)
+
+	fail := func() {
+		for i := 0; i < 2; i++ {
+			b.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	}
+
+	// The first ejection lasts the base ejection time
+	fail()
+	assert.True(t, b.IsEjected())
+	time.Sleep(1200 * time.Millisecond)
+	assert.False(t, b.IsEjected())
+
+	// The stats of the backend survive the window, so the second ejection lasts twice as long
+	fail()
+	assert.True(t, b.IsEjected())
+	time.Sleep(1300 * time.Millisecond)
+	assert.True(t, b.IsEjected())
+}
@@ -5,8 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,10 +13,10 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/coda-payments/load_balancer_rr/internal/config"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/admin"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/backend"
 	"github.com/coda-payments/load_balancer_rr/internal/handlers/healthcheck"
-	"github.com/coda-payments/load_balancer_rr/internal/handlers/load_balancer"
-	"github.com/coda-payments/load_balancer_rr/internal/handlers/serverpool"
+	"github.com/coda-payments/load_balancer_rr/internal/handlers/router"
 )
 
 // Launch configuring the server and register all BE routes
@@ -27,48 +25,104 @@ func Launch() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Initialize a new server pool with lb algorithm
-	serverPool, err := serverpool.NewServerPool()
+	services, err := ConfiguredServices()
 	if err != nil {
-		// Push alert here: Launch pool initialization failed
+		// Push alert here: Launch service configuration is invalid
 		config.Logger.Fatal(err.Error())
 	}
 
-	loadBalancer := load_balancer.NewLoadBalancer(serverPool)
-
 	//executing for all services
-	for _, routes := range config.Config.Backend.Routes {
+	serviceRouter := router.NewRouter()
+	routes := make(map[string]*router.Route, len(services))
+	for _, service := range services {
+		route, routeErr := NewRoute(service)
+		if routeErr != nil {
+			// Push alert here: Launch service initialization failed
+			config.Logger.Fatal(routeErr.Error(), zap.String("service", service.Name))
+		}
+
+		routes[service.Name] = route
+		if service.Name == DefaultServiceName {
+			serviceRouter.SetDefaultRoute(route)
+		} else {
+			serviceRouter.AddRoute(route)
+		}
 
-		// Parse backend URLs and add them to the server pool
-		parsedURL, parseErr := url.Parse(routes)
-		if parseErr != nil {
-			// Push alert here: URL parsing failed
-			config.Logger.Fatal(parseErr.Error(), zap.String("URL", routes))
+		probe, probeErr := backend.NewProbe(service.Backend)
+		if probeErr != nil {
+			// Push alert here: Launch health check configuration is invalid
+			config.Logger.Fatal(probeErr.Error(), zap.String("service", service.Name))
 		}
 
-		// Create a reverse proxy for the backend
-		reverseProxy := httputil.NewSingleHostReverseProxy(parsedURL)
+		//running a go routing to perform healthcheck on the instances of the service
+		go healthcheck.PerformHealthCheck(ctx, route.Pool, probe, service.Backend)
+
+		config.Logger.Info("added service", zap.String("service", service.Name),
+			zap.String("pathPrefix", service.PathPrefix), zap.String("host", service.Host))
+	}
+
+	// Register the routing rules in order, ahead of the services path prefix and host matching
+	for _, rule := range config.Config.Rules {
+		route, ok := routes[rule.Service]
+		if !ok {
+			// Push alert here: Launch routing rule targets an unknown service
+			config.Logger.Fatal("routing rule targets an unknown service", zap.String("service", rule.Service))
+		}
+		if ruleErr := serviceRouter.AddRule(rule, route); ruleErr != nil {
+			config.Logger.Fatal(ruleErr.Error(), zap.String("service", rule.Service))
+		}
+	}
 
-		// Create a new backend server and add it to the pool
-		backendServer := backend.NewBackendServer(parsedURL, reverseProxy)
+	// Split off the configured share of the requests of the services to their canary
+	for _, service := range services {
+		if service.Canary.Service == "" {
+			continue
+		}
+		canaryRoute, ok := routes[service.Canary.Service]
+		if !ok {
+			// Push alert here: Launch canary targets an unknown service
+			config.Logger.Fatal("canary targets an unknown service", zap.String("service", service.Name),
+				zap.String("canary", service.Canary.Service))
+		}
+		canary, canaryErr := router.NewCanary(service.Canary, canaryRoute)
+		if canaryErr != nil {
+			config.Logger.Fatal(canaryErr.Error(), zap.String("service", service.Name))
+		}
+		routes[service.Name].Canary = canary
+	}
 
-		serverPool.RegisterServiceBackend(backendServer)
+	// Shadow the configured share of the requests of the services to their mirror
+	for _, service := range services {
+		if service.Mirror.Service == "" {
+			continue
+		}
+		mirrorRoute, ok := routes[service.Mirror.Service]
+		if !ok {
+			// Push alert here: Launch mirror targets an unknown service
+			config.Logger.Fatal("mirror targets an unknown service", zap.String("service", service.Name),
+				zap.String("mirror", service.Mirror.Service))
+		}
+		mirror, mirrorErr := router.NewMirror(service.Mirror, mirrorRoute)
+		if mirrorErr != nil {
+			config.Logger.Fatal(mirrorErr.Error(), zap.String("service", service.Name))
+		}
+		routes[service.Name].Mirror = mirror
+	}
 
-		config.Logger.Info("added server", zap.String("host: ", backendServer.GetURL().Host))
+	if config.Config.Server.AdminPort != 0 {
+		go serveAdmin(ctx, serviceRouter)
 	}
+
 	// Configure the HTTP server
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.Config.Server.Port),
-		Handler:      http.HandlerFunc(loadBalancer.Serve),
+		Handler:      http.HandlerFunc(serviceRouter.Serve),
 		WriteTimeout: time.Duration(config.Config.Server.WriteTimeout) * time.Second,
 		ReadTimeout:  time.Duration(config.Config.Server.ReadTimeout) * time.Second,
 	}
 
 	config.GracefulShutdownConfig(ctx, server)
 
-	//running a go routing to perform healthcheck on the instances
-	go healthcheck.PerformHealthCheck(ctx, serverPool)
-
 	config.Logger.Info("Load Balancer is running successfully", zap.Int("port", config.Config.Server.Port))
 
 	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
@@ -76,3 +130,22 @@ func Launch() {
 		config.Logger.Fatal("Launch encountered an unexpected error", zap.Error(err))
 	}
 }
+
+// serveAdmin runs the admin API on its own port, apart from the proxied traffic.
+func serveAdmin(ctx context.Context, serviceRouter *router.Router) {
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", config.Config.Server.AdminPort),
+		Handler:      admin.New(serviceRouter, config.Config.Server.AdminToken),
+		WriteTimeout: time.Duration(config.Config.Server.WriteTimeout) * time.Second,
+		ReadTimeout:  time.Duration(config.Config.Server.ReadTimeout) * time.Second,
+	}
+
+	config.GracefulShutdownConfig(ctx, server)
+
+	config.Logger.Info("Admin API is running successfully", zap.Int("port", config.Config.Server.AdminPort))
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		// Push alert here: serveAdmin encountered an unexpected error
+		config.Logger.Error("Admin API encountered an unexpected error", zap.Error(err))
+	}
+}
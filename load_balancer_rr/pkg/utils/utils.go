@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 const (
@@ -34,6 +35,14 @@ func ValidatePort(port int) error {
 	return nil
 }
 
+// SecondsOrDefault converts a config value in seconds to a duration, returning the fallback when it is not set.
+func SecondsOrDefault(value int, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return time.Duration(value) * time.Second
+}
+
 // getOccupiedPorts returns a slice of occupied port numbers.
 func getOccupiedPorts() ([]int, error) {
 	// Execute the lsof command to list all listening TCP ports
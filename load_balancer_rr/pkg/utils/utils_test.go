@@ -2,6 +2,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 )
 
 func TestValidatePort(t *testing.T) {
@@ -31,3 +32,14 @@ func TestValidatePort(t *testing.T) {
 		}
 	}
 }
+
+func TestSecondsOrDefault(t *testing.T) {
+	if got := SecondsOrDefault(5, time.Minute); got != 5*time.Second {
+		t.Errorf("Expected 5s, got %v", got)
+	}
+	for _, value := range []int{0, -1} {
+		if got := SecondsOrDefault(value, time.Minute); got != time.Minute {
+			t.Errorf("Expected the fallback for %d, got %v", value, got)
+		}
+	}
+}